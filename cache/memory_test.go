@@ -13,6 +13,9 @@ func Test_NewMemoryCache(t *testing.T) {
 
 	client := NewMemoryCache(WithCodec(codec.JSONEncoding{}), WithPrefix("demo"))
 	asserts.NotNil(client)
+
+	asserts.NotNil(NewMemoryCache(WithCodecName("MsgPack+Zstd")))
+	asserts.Panics(func() { WithCodecName("msgpak+zstd") })
 }
 
 func TestMemoStore_Set(t *testing.T) {
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/binbinly/pkg/codec"
@@ -45,3 +46,14 @@ func WithCodec(codec codec.Encoding) Option {
 		o.codec = codec
 	}
 }
+
+// WithCodecName 通过注册名选择编码, 如: json, msgpack+zstd, 未注册时 panic, 避免拼写错误时静默使用其他编码
+func WithCodecName(name string) Option {
+	c := codec.GetCodec(name)
+	if c == nil {
+		panic(fmt.Sprintf("cache: codec %q not registered", name))
+	}
+	return func(o *Options) {
+		o.codec = c
+	}
+}
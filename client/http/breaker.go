@@ -0,0 +1,296 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// State 熔断器状态
+type State int
+
+const (
+	// StateClosed 关闭, 请求正常通过
+	StateClosed State = iota
+	// StateOpen 打开, 请求直接失败
+	StateOpen
+	// StateHalfOpen 半开, 允许少量探测请求通过
+	StateHalfOpen
+)
+
+// String 状态名
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown state: %d", s)
+	}
+}
+
+// ErrCircuitOpen 熔断器打开
+var ErrCircuitOpen = errors.New("http: circuit breaker is open")
+
+// CircuitOpenError 熔断器打开时返回的错误, errors.Is(err, ErrCircuitOpen) 为 true
+type CircuitOpenError struct {
+	Host  string
+	State State
+}
+
+// Error 格式化
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("http: circuit breaker is %s, host: %s", e.State, e.Host)
+}
+
+// Unwrap returns ErrCircuitOpen
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// BreakerConfig 熔断器配置, 零值字段使用默认值
+type BreakerConfig struct {
+	// Window 错误率统计窗口, 默认10s
+	Window time.Duration
+	// Buckets 窗口分桶数, 默认10
+	Buckets int
+	// MinRequests 窗口内最少请求数, 达到后才计算错误率, 默认20
+	MinRequests int
+	// ErrorRate 触发熔断的错误率, 默认0.5
+	ErrorRate float64
+	// CoolDown 打开状态持续时间, 之后进入半开状态, 默认5s
+	CoolDown time.Duration
+	// HalfOpenProbes 半开状态允许的探测请求数, 全部成功后关闭熔断, 默认1
+	HalfOpenProbes int
+	// IsFailure 判断请求是否失败, 默认请求错误或状态码>=500, 调用方 context 取消或超时不计为失败
+	IsFailure func(resp *http.Response, err error) bool
+	// OnStateChange 状态变更回调
+	OnStateChange func(host string, from, to State)
+}
+
+// Breaker 按 host 熔断
+type Breaker struct {
+	cfg      BreakerConfig
+	mu       sync.Mutex
+	breakers map[string]*hostBreaker
+}
+
+// NewBreaker 实例化熔断器
+func NewBreaker(cfg BreakerConfig) *Breaker {
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.Buckets <= 0 {
+		cfg.Buckets = 10
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 20
+	}
+	if cfg.ErrorRate <= 0 {
+		cfg.ErrorRate = 0.5
+	}
+	if cfg.CoolDown <= 0 {
+		cfg.CoolDown = 5 * time.Second
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = isFailure
+	}
+	return &Breaker{
+		cfg:      cfg,
+		breakers: make(map[string]*hostBreaker),
+	}
+}
+
+// isFailure 默认的失败判断, context 取消或超时由调用方导致, 不代表 host 不可用
+func isFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// State 获取 host 当前状态
+func (b *Breaker) State(host string) State {
+	hb := b.get(host)
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+	return hb.current(time.Now())
+}
+
+func (b *Breaker) get(host string) *hostBreaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	hb, ok := b.breakers[host]
+	if !ok {
+		hb = &hostBreaker{
+			cfg:     &b.cfg,
+			host:    host,
+			buckets: make([]bucket, b.cfg.Buckets),
+		}
+		b.breakers[host] = hb
+	}
+	return hb
+}
+
+// bucket 窗口分桶
+type bucket struct {
+	start    time.Time
+	total    int
+	failures int
+}
+
+// hostBreaker 单个 host 的熔断器
+type hostBreaker struct {
+	cfg      *BreakerConfig
+	host     string
+	mu       sync.Mutex
+	state    State
+	buckets  []bucket
+	openedAt time.Time
+	probes   int // 半开状态已放行的探测请求数
+	passed   int // 半开状态成功的探测请求数
+	changes  []State
+}
+
+// allow 判断请求是否可以通过
+func (hb *hostBreaker) allow() error {
+	hb.mu.Lock()
+	now := time.Now()
+	state := hb.current(now)
+	var err error
+	switch state {
+	case StateOpen:
+		err = &CircuitOpenError{Host: hb.host, State: state}
+	case StateHalfOpen:
+		if hb.probes >= hb.cfg.HalfOpenProbes {
+			err = &CircuitOpenError{Host: hb.host, State: state}
+		} else {
+			hb.probes++
+		}
+	}
+	changes := hb.flush()
+	hb.mu.Unlock()
+
+	hb.notify(changes)
+	return err
+}
+
+// record 记录请求结果
+func (hb *hostBreaker) record(failure bool) {
+	hb.mu.Lock()
+	now := time.Now()
+	switch hb.current(now) {
+	case StateClosed:
+		b := hb.bucket(now)
+		b.total++
+		if failure {
+			b.failures++
+		}
+		total, failures := hb.sum(now)
+		if total >= hb.cfg.MinRequests && float64(failures)/float64(total) >= hb.cfg.ErrorRate {
+			hb.setState(StateOpen, now)
+		}
+	case StateHalfOpen:
+		if failure {
+			hb.setState(StateOpen, now)
+		} else if hb.passed++; hb.passed >= hb.cfg.HalfOpenProbes {
+			hb.setState(StateClosed, now)
+		}
+	}
+	changes := hb.flush()
+	hb.mu.Unlock()
+
+	hb.notify(changes)
+}
+
+// current 获取当前状态, 打开状态冷却结束后进入半开
+func (hb *hostBreaker) current(now time.Time) State {
+	if hb.state == StateOpen && now.Sub(hb.openedAt) >= hb.cfg.CoolDown {
+		hb.setState(StateHalfOpen, now)
+	}
+	return hb.state
+}
+
+func (hb *hostBreaker) setState(state State, now time.Time) {
+	if hb.state == state {
+		return
+	}
+	hb.changes = append(hb.changes, hb.state, state)
+	hb.state = state
+	hb.probes, hb.passed = 0, 0
+	switch state {
+	case StateOpen:
+		hb.openedAt = now
+	case StateClosed:
+		for i := range hb.buckets {
+			hb.buckets[i] = bucket{}
+		}
+	}
+}
+
+// flush 取出待通知的状态变更, 需持有锁
+func (hb *hostBreaker) flush() []State {
+	changes := hb.changes
+	hb.changes = nil
+	return changes
+}
+
+// notify 在锁外执行回调
+func (hb *hostBreaker) notify(changes []State) {
+	if hb.cfg.OnStateChange == nil {
+		return
+	}
+	for i := 0; i+1 < len(changes); i += 2 {
+		hb.cfg.OnStateChange(hb.host, changes[i], changes[i+1])
+	}
+}
+
+// bucket 获取当前时间所在的分桶
+func (hb *hostBreaker) bucket(now time.Time) *bucket {
+	size := hb.cfg.Window / time.Duration(len(hb.buckets))
+	start := now.Truncate(size)
+	b := &hb.buckets[int(start.UnixNano()/int64(size))%len(hb.buckets)]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+	return b
+}
+
+// sum 统计窗口内的请求数及失败数
+func (hb *hostBreaker) sum(now time.Time) (total, failures int) {
+	for _, b := range hb.buckets {
+		if now.Sub(b.start) < hb.cfg.Window {
+			total += b.total
+			failures += b.failures
+		}
+	}
+	return
+}
+
+// breakerTransport 按 host 熔断
+type breakerTransport struct {
+	next    http.RoundTripper
+	breaker *Breaker
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	hb := t.breaker.get(req.URL.Host)
+	if err := hb.allow(); err != nil {
+		closeBody(req)
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	hb.record(t.breaker.cfg.IsFailure(resp, err))
+	return resp, err
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	var (
+		failing int32 = 1
+		calls   int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	var (
+		mu      sync.Mutex
+		changes []State
+	)
+	b := NewBreaker(BreakerConfig{
+		MinRequests: 4,
+		ErrorRate:   0.5,
+		CoolDown:    50 * time.Millisecond,
+		OnStateChange: func(host string, from, to State) {
+			mu.Lock()
+			changes = append(changes, to)
+			mu.Unlock()
+		},
+	})
+	host := srv.Listener.Addr().String()
+
+	for _, client := range []Client{NewRawClientWithOptions(WithBreaker(b)), NewRestyClient(WithBreaker(b))} {
+		atomic.StoreInt32(&failing, 1)
+		atomic.StoreInt32(&calls, 0)
+		for i := 0; i < 4; i++ {
+			_, _ = client.Get(context.Background(), srv.URL)
+		}
+		assert.Equal(t, StateOpen, b.State(host))
+
+		// 打开状态快速失败, 不会请求服务端
+		_, err := client.Get(context.Background(), srv.URL)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		var openErr *CircuitOpenError
+		assert.ErrorAs(t, err, &openErr)
+		assert.Equal(t, host, openErr.Host)
+		assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+
+		// 冷却后半开, 探测成功后关闭
+		time.Sleep(60 * time.Millisecond)
+		atomic.StoreInt32(&failing, 0)
+		b2, err := client.Get(context.Background(), srv.URL)
+		assert.Nil(t, err)
+		assert.Equal(t, "ok", string(b2))
+		assert.Equal(t, StateClosed, b.State(host))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []State{StateOpen, StateHalfOpen, StateClosed, StateOpen, StateHalfOpen, StateClosed}, changes)
+}
+
+func TestBreakerHalfOpenFailure(t *testing.T) {
+	b := NewBreaker(BreakerConfig{MinRequests: 1, CoolDown: 10 * time.Millisecond, HalfOpenProbes: 2})
+	hb := b.get("example.com")
+
+	assert.Nil(t, hb.allow())
+	hb.record(true)
+	assert.Equal(t, StateOpen, b.State("example.com"))
+
+	time.Sleep(15 * time.Millisecond)
+	assert.Nil(t, hb.allow())
+	assert.Nil(t, hb.allow())
+	// 探测请求数已满
+	assert.ErrorIs(t, hb.allow(), ErrCircuitOpen)
+	hb.record(false)
+	hb.record(true)
+	assert.Equal(t, StateOpen, b.State("example.com"))
+}
+
+func TestBreakerContextError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	b := NewBreaker(BreakerConfig{MinRequests: 2})
+	client := NewRawClientWithOptions(WithBreaker(b))
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, err := client.Get(ctx, srv.URL)
+		cancel()
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+	// 调用方超时不计为失败
+	assert.Equal(t, StateClosed, b.State(srv.Listener.Addr().String()))
+
+	assert.False(t, b.cfg.IsFailure(nil, context.Canceled))
+	assert.True(t, b.cfg.IsFailure(nil, errors.New("connection refused")))
+	assert.True(t, b.cfg.IsFailure(&http.Response{StatusCode: http.StatusBadGateway}, nil))
+	assert.False(t, b.cfg.IsFailure(&http.Response{StatusCode: http.StatusNotFound}, nil))
+}
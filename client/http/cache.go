@@ -0,0 +1,392 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/binbinly/pkg/cache"
+)
+
+// HeaderCache 响应缓存状态头, 值为 CacheStatus
+const HeaderCache = "X-Cache"
+
+// CacheStatus 响应缓存状态
+type CacheStatus string
+
+const (
+	// CacheHit 命中缓存, 未请求服务端
+	CacheHit CacheStatus = "HIT"
+	// CacheMiss 未命中缓存
+	CacheMiss CacheStatus = "MISS"
+	// CacheRevalidated 缓存过期, 服务端返回 304 后使用缓存
+	CacheRevalidated CacheStatus = "REVALIDATED"
+	// CacheStale 请求失败, 服务端允许 stale-if-error 时使用过期缓存
+	CacheStale CacheStatus = "STALE"
+)
+
+// CacheOption configures the http response cache
+type CacheOption func(*cacheOptions)
+
+type cacheOptions struct {
+	key     func(req *http.Request) string
+	report  func(key string, status CacheStatus)
+	ttl     time.Duration
+	maxBody int64
+	private bool
+}
+
+// WithCacheKey specifies the cache key of request, an empty key skips the cache.
+func WithCacheKey(fn func(req *http.Request) string) CacheOption {
+	return func(o *cacheOptions) {
+		o.key = fn
+	}
+}
+
+// WithCacheReport specifies the callback of cache hit and miss.
+func WithCacheReport(fn func(key string, status CacheStatus)) CacheOption {
+	return func(o *cacheOptions) {
+		o.report = fn
+	}
+}
+
+// WithCacheTTL specifies how long a response with ETag or Last-Modified is kept for revalidation, default 1h.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithCacheMaxBody specifies the max response body size to cache, default 1MB.
+func WithCacheMaxBody(n int64) CacheOption {
+	return func(o *cacheOptions) {
+		o.maxBody = n
+	}
+}
+
+// WithCachePrivate allows caching Cache-Control private responses and responses of requests with Authorization,
+// only use it when the client is not shared between users.
+func WithCachePrivate() CacheOption {
+	return func(o *cacheOptions) {
+		o.private = true
+	}
+}
+
+// WithCache specifies the cache to store GET responses, Cache-Control, Vary and validators are honoured.
+// Responses of requests with Authorization are stored only when marked public, s-maxage or must-revalidate.
+func WithCache(c cache.Cache, opts ...CacheOption) Option {
+	return func(o *options) {
+		co := cacheOptions{
+			key:     defaultCacheKey,
+			ttl:     time.Hour,
+			maxBody: 1 << 20,
+		}
+		for _, f := range opts {
+			f(&co)
+		}
+		o.cache = &cacheTransport{cache: c, opts: co}
+	}
+}
+
+func defaultCacheKey(req *http.Request) string {
+	return "http:" + req.Method + ":" + req.URL.String()
+}
+
+// cacheEntry 缓存的响应
+type cacheEntry struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"stored_at"`
+	// FreshUntil 新鲜期截止时间, 之后需要重新验证
+	FreshUntil time.Time `json:"fresh_until"`
+	// StaleUntil 请求失败时可使用过期缓存的截止时间
+	StaleUntil time.Time `json:"stale_until"`
+	// Vary 不为空时为索引, 响应按 Vary 的请求头保存在 variantKey 中
+	Vary []string `json:"vary,omitempty"`
+}
+
+// cacheTransport 缓存 GET 请求的响应
+type cacheTransport struct {
+	next  http.RoundTripper
+	cache cache.Cache
+	opts  cacheOptions
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" ||
+		req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return t.next.RoundTrip(req)
+	}
+	reqCC := parseCacheControl(req.Header)
+	if _, ok := reqCC["no-store"]; ok {
+		return t.next.RoundTrip(req)
+	}
+	key := t.opts.key(req)
+	if key == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	entry, entryKey := t.lookup(req, key)
+	cached := !entry.StoredAt.IsZero()
+	now := time.Now()
+
+	_, noCache := reqCC["no-cache"]
+	if cached && !noCache && now.Before(entry.FreshUntil) {
+		t.report(key, CacheHit)
+		return entry.response(req, CacheHit, now), nil
+	}
+
+	outReq := req
+	if cached {
+		outReq = cloneRequest(req)
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			outReq.Header.Set("If-None-Match", etag)
+		}
+		if lm := entry.Header.Get("Last-Modified"); lm != "" {
+			outReq.Header.Set("If-Modified-Since", lm)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(outReq)
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		if cached && now.Before(entry.StaleUntil) {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			t.report(key, CacheStale)
+			return entry.response(req, CacheStale, now), nil
+		}
+		return resp, err
+	}
+
+	if cached && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		for k, vs := range resp.Header {
+			if k != "Content-Length" {
+				entry.Header[k] = vs
+			}
+		}
+		entry.StoredAt = now
+		t.store(req, key, &entry, now)
+		t.report(key, CacheRevalidated)
+		return entry.response(req, CacheRevalidated, now), nil
+	}
+
+	t.report(key, CacheMiss)
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	// 响应体超过上限时不缓存, 已读取部分与剩余部分拼接返回
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.opts.maxBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > t.opts.maxBody {
+		resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), resp.Body), Closer: resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry = cacheEntry{StatusCode: resp.StatusCode, Header: resp.Header.Clone(), Body: body, StoredAt: now}
+	if !t.store(req, key, &entry, now) && cached {
+		_ = t.cache.Del(ctx, entryKey)
+	}
+	resp.Header.Set(HeaderCache, string(CacheMiss))
+	return resp, nil
+}
+
+// lookup 查找缓存, 返回缓存及其所在的 key, 响应有 Vary 时按请求头查找对应的变体
+func (t *cacheTransport) lookup(req *http.Request, key string) (cacheEntry, string) {
+	var entry cacheEntry
+	if err := t.cache.Get(req.Context(), key, &entry); err != nil || entry.StoredAt.IsZero() {
+		return cacheEntry{}, key
+	}
+	if len(entry.Vary) == 0 {
+		return entry, key
+	}
+
+	vk := variantKey(key, entry.Vary, req)
+	var variant cacheEntry
+	if err := t.cache.Get(req.Context(), vk, &variant); err != nil || variant.StoredAt.IsZero() {
+		return cacheEntry{}, vk
+	}
+	return variant, vk
+}
+
+// store 按 Cache-Control 计算新鲜期并保存, 不可缓存时返回 false
+func (t *cacheTransport) store(req *http.Request, key string, entry *cacheEntry, now time.Time) bool {
+	cc := parseCacheControl(entry.Header)
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	if _, ok := cc["private"]; ok && !t.opts.private {
+		return false
+	}
+	// RFC 7234 3.2: 共享缓存只保存明确允许的带 Authorization 请求的响应
+	if req.Header.Get("Authorization") != "" && !t.opts.private {
+		_, public := cc["public"]
+		_, sMaxAge := cc["s-maxage"]
+		_, mustRevalidate := cc["must-revalidate"]
+		if !public && !sMaxAge && !mustRevalidate {
+			return false
+		}
+	}
+	vary := parseVary(entry.Header)
+	if len(vary) == 1 && vary[0] == "*" {
+		return false
+	}
+
+	var freshness time.Duration
+	if _, ok := cc["no-cache"]; !ok {
+		freshness = t.freshness(cc, entry.Header, now)
+	}
+	validator := entry.Header.Get("ETag") != "" || entry.Header.Get("Last-Modified") != ""
+	if freshness <= 0 && !validator {
+		return false
+	}
+
+	entry.FreshUntil = now.Add(freshness)
+	entry.StaleUntil = time.Time{}
+	_, mustRevalidate := cc["must-revalidate"]
+	if v, ok := cc["stale-if-error"]; ok && !mustRevalidate {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			entry.StaleUntil = entry.FreshUntil.Add(time.Duration(secs) * time.Second)
+		}
+	}
+
+	ttl := freshness
+	if !entry.StaleUntil.IsZero() {
+		ttl = entry.StaleUntil.Sub(now)
+	}
+	if validator && ttl < t.opts.ttl {
+		ttl = t.opts.ttl
+	}
+	if len(vary) == 0 {
+		return t.cache.Set(req.Context(), key, entry, ttl) == nil
+	}
+	index := cacheEntry{StoredAt: entry.StoredAt, Vary: vary}
+	if err := t.cache.Set(req.Context(), key, &index, ttl); err != nil {
+		return false
+	}
+	return t.cache.Set(req.Context(), variantKey(key, vary, req), entry, ttl) == nil
+}
+
+// parseVary 解析 Vary 响应头, 返回排序后的规范请求头名称, 包含 * 时只返回 *
+func parseVary(header http.Header) []string {
+	var names []string
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if name == "*" {
+				return []string{"*"}
+			}
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// variantKey Vary 请求头对应的缓存 key
+func variantKey(key string, vary []string, req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range vary {
+		b.WriteString("|")
+		b.WriteString(name)
+		b.WriteString("=")
+		b.WriteString(strings.Join(req.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// freshness 新鲜期, 共享缓存优先使用 s-maxage, 其次 max-age 及 Expires, 并扣除 Age
+func (t *cacheTransport) freshness(cc map[string]string, header http.Header, now time.Time) time.Duration {
+	var d time.Duration
+	if v, ok := cc["s-maxage"]; ok && !t.opts.private {
+		d = parseSeconds(v)
+	} else if v, ok = cc["max-age"]; ok {
+		d = parseSeconds(v)
+	} else if v := header.Get("Expires"); v != "" {
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			return 0
+		}
+		date := now
+		if dv, err := http.ParseTime(header.Get("Date")); err == nil {
+			date = dv
+		}
+		d = expires.Sub(date)
+	}
+	if age := header.Get("Age"); age != "" {
+		d -= parseSeconds(age)
+	}
+	return d
+}
+
+func (t *cacheTransport) report(key string, status CacheStatus) {
+	if t.opts.report != nil {
+		t.opts.report(key, status)
+	}
+}
+
+// response 使用缓存构建响应
+func (e *cacheEntry) response(req *http.Request, status CacheStatus, now time.Time) *http.Response {
+	header := e.Header.Clone()
+	header.Set(HeaderCache, string(status))
+	header.Set("Age", strconv.Itoa(int(now.Sub(e.StoredAt).Seconds())))
+	return &http.Response{
+		Status:        strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// parseCacheControl 解析 Cache-Control 指令, 指令名小写
+func parseCacheControl(header http.Header) map[string]string {
+	cc := make(map[string]string)
+	for _, v := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(v, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, val, _ := strings.Cut(part, "=")
+			cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(val), `"`)
+		}
+	}
+	return cc
+}
+
+func parseSeconds(v string) time.Duration {
+	secs, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// readCloser 组合 Reader 及 Closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
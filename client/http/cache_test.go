@@ -0,0 +1,252 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mapCache 同步的内存缓存, 用于测试
+type mapCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{data: make(map[string][]byte)}
+}
+
+func (m *mapCache) Set(ctx context.Context, key string, val any, expiration time.Duration) error {
+	b, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = b
+	return nil
+}
+
+func (m *mapCache) Get(ctx context.Context, key string, val any) error {
+	m.mu.Lock()
+	b, ok := m.data[key]
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, val)
+}
+
+func (m *mapCache) MultiSet(ctx context.Context, valMap map[string]any, expiration time.Duration) error {
+	return nil
+}
+
+func (m *mapCache) MultiGet(ctx context.Context, keys []string, valueMap any, newObject func() any) error {
+	return nil
+}
+
+func (m *mapCache) Del(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, k := range keys {
+		delete(m.data, k)
+	}
+	return nil
+}
+
+func (m *mapCache) SetCacheWithNotFound(ctx context.Context, key string) error {
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	var (
+		calls   int32
+		failing int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache, stale-if-error=60")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/nostore":
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		_, _ = w.Write([]byte("body:" + r.URL.Path))
+	}))
+	defer srv.Close()
+
+	var (
+		mu       sync.Mutex
+		statuses []CacheStatus
+	)
+	c := newMapCache()
+	opts := []Option{WithCache(c, WithCacheReport(func(key string, status CacheStatus) {
+		mu.Lock()
+		statuses = append(statuses, status)
+		mu.Unlock()
+	}))}
+
+	for _, client := range []Client{NewRawClientWithOptions(opts...), NewRestyClient(opts...)} {
+		c.data = make(map[string][]byte)
+		atomic.StoreInt32(&calls, 0)
+		atomic.StoreInt32(&failing, 0)
+		mu.Lock()
+		statuses = nil
+		mu.Unlock()
+		ctx := context.Background()
+
+		resp, err := client.Do(ctx, http.MethodGet, srv.URL+"/fresh", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "MISS", resp.Header.Get(HeaderCache))
+		resp, err = client.Do(ctx, http.MethodGet, srv.URL+"/fresh", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "HIT", resp.Header.Get(HeaderCache))
+		assert.Equal(t, "body:/fresh", string(resp.Body))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+		// 请求 no-cache 强制重新请求
+		_, err = client.Get(ctx, srv.URL+"/fresh", WithHTTPHeader("Cache-Control", "no-cache"))
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+		// ETag 重新验证
+		_, _ = client.Get(ctx, srv.URL+"/etag")
+		resp, err = client.Do(ctx, http.MethodGet, srv.URL+"/etag", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "REVALIDATED", resp.Header.Get(HeaderCache))
+		assert.Equal(t, "body:/etag", string(resp.Body))
+
+		// 服务端出错时使用过期缓存
+		atomic.StoreInt32(&failing, 1)
+		resp, err = client.Do(ctx, http.MethodGet, srv.URL+"/etag", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "STALE", resp.Header.Get(HeaderCache))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		atomic.StoreInt32(&failing, 0)
+
+		// private 及 no-store 不缓存
+		for _, path := range []string{"/private", "/nostore"} {
+			_, _ = client.Get(ctx, srv.URL+path)
+			resp, err = client.Do(ctx, http.MethodGet, srv.URL+path, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, "MISS", resp.Header.Get(HeaderCache))
+		}
+
+		mu.Lock()
+		assert.Equal(t, []CacheStatus{CacheMiss, CacheHit, CacheMiss, CacheMiss, CacheRevalidated, CacheStale,
+			CacheMiss, CacheMiss, CacheMiss, CacheMiss}, statuses)
+		mu.Unlock()
+	}
+}
+
+func TestCacheKeyAndFreshness(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	// 忽略 query 的缓存 key
+	client := NewRawClientWithOptions(WithCache(newMapCache(), WithCacheKey(func(req *http.Request) string {
+		return req.URL.Path
+	})))
+	_, _ = client.Get(context.Background(), srv.URL+"/a?t=1")
+	_, _ = client.Get(context.Background(), srv.URL+"/a?t=2")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	tr := &cacheTransport{}
+	now := time.Now()
+	header := http.Header{}
+	header.Set("Age", "10")
+	assert.Equal(t, 50*time.Second, tr.freshness(map[string]string{"max-age": "60"}, header, now))
+	assert.Equal(t, 20*time.Second, tr.freshness(map[string]string{"max-age": "60", "s-maxage": "30"}, header, now))
+	header = http.Header{}
+	header.Set("Date", now.UTC().Format(http.TimeFormat))
+	header.Set("Expires", now.Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.InDelta(t, float64(time.Minute), float64(tr.freshness(map[string]string{}, header, now)), float64(time.Second))
+
+	assert.Equal(t, map[string]string{"max-age": "60", "private": "", "stale-if-error": "5"},
+		parseCacheControl(http.Header{"Cache-Control": {`Private, max-age="60"`, "stale-if-error=5"}}))
+}
+
+func TestCacheAuthorizationAndVary(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/auth":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/public":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		case "/shared":
+			w.Header().Set("Cache-Control", "s-maxage=60")
+		case "/lang":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "accept-language")
+		case "/any":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "*")
+		}
+		_, _ = w.Write([]byte(r.URL.Path + ":" + r.Header.Get("Accept-Language")))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	client := NewRawClientWithOptions(WithCache(newMapCache()))
+	get := func(path string, header ...string) *Response {
+		resp, err := client.Do(ctx, http.MethodGet, srv.URL+path, nil, WithHTTPHeader(header[0], header[1]))
+		assert.NoError(t, err)
+		return resp
+	}
+
+	// 带 Authorization 的请求只缓存 public, s-maxage 的响应
+	get("/auth", "Authorization", "Bearer a")
+	assert.Equal(t, "MISS", get("/auth", "Authorization", "Bearer b").Header.Get(HeaderCache))
+	for _, path := range []string{"/public", "/shared"} {
+		get(path, "Authorization", "Bearer a")
+		assert.Equal(t, "HIT", get(path, "Authorization", "Bearer b").Header.Get(HeaderCache))
+	}
+
+	// Vary 的请求头不同时分别缓存
+	atomic.StoreInt32(&calls, 0)
+	get("/lang", "Accept-Language", "en")
+	get("/lang", "Accept-Language", "zh")
+	resp := get("/lang", "Accept-Language", "en")
+	assert.Equal(t, "HIT", resp.Header.Get(HeaderCache))
+	assert.Equal(t, "/lang:en", string(resp.Body))
+	resp = get("/lang", "Accept-Language", "zh")
+	assert.Equal(t, "HIT", resp.Header.Get(HeaderCache))
+	assert.Equal(t, "/lang:zh", string(resp.Body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// Vary: * 不缓存
+	get("/any", "Accept-Language", "en")
+	assert.Equal(t, "MISS", get("/any", "Accept-Language", "en").Header.Get(HeaderCache))
+
+	// WithCachePrivate 时缓存带 Authorization 的响应
+	client = NewRawClientWithOptions(WithCache(newMapCache(), WithCachePrivate()))
+	get("/auth", "Authorization", "Bearer a")
+	assert.Equal(t, "HIT", get("/auth", "Authorization", "Bearer a").Header.Get(HeaderCache))
+}
@@ -2,20 +2,33 @@ package http
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
+	"net/url"
 	"time"
+
+	"github.com/binbinly/pkg/codec"
 )
 
 // defaultTimeout default http request timeout
 const defaultTimeout = 10 * time.Second
 
+// ErrUnsupportedMediaType 请求或响应的 Content-Type 没有对应的编码
+var ErrUnsupportedMediaType = errors.New("http: unsupported media type")
+
 // httpSettings http request options
 type httpSettings struct {
-	headers map[string]string
-	cookies []*http.Cookie
-	close   bool
-	debug   bool
-	timeout time.Duration
+	headers     map[string]string
+	cookies     []*http.Cookie
+	close       bool
+	debug       bool
+	timeout     time.Duration
+	contentType string
+	retry       *RetryPolicy
+	query       url.Values
+	progress    ProgressFunc
+	checksum    string
 }
 
 // ClientOption HTTPOption configures how we set up the http request
@@ -44,20 +57,30 @@ func WithHTTPClose() ClientOption {
 	}
 }
 
-// WithDebug specifies debug
+// WithDebug specifies to log the request and response with headers and bodies through logger,
+// secrets such as Authorization and password are redacted.
 func WithDebug() ClientOption {
 	return func(s *httpSettings) {
 		s.debug = true
 	}
 }
 
-// WithHTTPTimeout specifies the timeout to http request.
+// WithHTTPTimeout specifies the timeout to http request,
+// for Upload and Download it limits the idle time without reading or writing the body instead of the whole transfer.
 func WithHTTPTimeout(timeout time.Duration) ClientOption {
 	return func(s *httpSettings) {
 		s.timeout = timeout
 	}
 }
 
+// WithContentType specifies the content type used to encode the request body of PostData,
+// the codec is looked up from codec registry, default application/json.
+func WithContentType(contentType string) ClientOption {
+	return func(s *httpSettings) {
+		s.contentType = contentType
+	}
+}
+
 // Client 定义 http client 接口
 type Client interface {
 	// Get sends an HTTP get request
@@ -69,6 +92,83 @@ type Client interface {
 	// PostJSON sends an HTTP post request with json
 	PostJSON(ctx context.Context, reqURL string, body []byte, options ...ClientOption) ([]byte, error)
 
-	// Upload sends an HTTP post request for uploading media
+	// Put sends an HTTP put request
+	Put(ctx context.Context, reqURL string, body []byte, options ...ClientOption) ([]byte, error)
+
+	// Patch sends an HTTP patch request
+	Patch(ctx context.Context, reqURL string, body []byte, options ...ClientOption) ([]byte, error)
+
+	// Delete sends an HTTP delete request
+	Delete(ctx context.Context, reqURL string, options ...ClientOption) ([]byte, error)
+
+	// Head sends an HTTP head request
+	Head(ctx context.Context, reqURL string, options ...ClientOption) (*Response, error)
+
+	// Do sends an HTTP request and returns the response with status and headers,
+	// a non 2xx status returns both the response and an *HTTPError
+	Do(ctx context.Context, method, reqURL string, body []byte, options ...ClientOption) (*Response, error)
+
+	// PostData sends an HTTP post request, the body is encoded from data by the codec of
+	// WithContentType, and the response is decoded into reply by its Content-Type
+	PostData(ctx context.Context, reqURL string, data, reply any, options ...ClientOption) error
+
+	// Upload sends an HTTP post request for uploading media, the multipart body is streamed
 	Upload(ctx context.Context, reqURL string, form UploadForm, options ...ClientOption) ([]byte, error)
+
+	// Download sends an HTTP get request and streams the response body into w,
+	// returns the number of bytes written by this call
+	Download(ctx context.Context, reqURL string, w io.Writer, options ...ClientOption) (int64, error)
+}
+
+// applySettings 应用请求选项
+func applySettings(options []ClientOption) *httpSettings {
+	settings := &httpSettings{headers: make(map[string]string)}
+	for _, f := range options {
+		f(settings)
+	}
+	return settings
+}
+
+// requestCodec 获取请求体编码, 返回编码及 Content-Type
+func requestCodec(options []ClientOption) (codec.Codec, string, error) {
+	settings := applySettings(options)
+	if settings.contentType == "" {
+		return codec.JSONEncoding{}, codec.MIMEJSON, nil
+	}
+	c := codec.GetCodecByContentType(settings.contentType)
+	if c == nil {
+		return nil, "", ErrUnsupportedMediaType
+	}
+	return c, settings.contentType, nil
+}
+
+// encodeRequest 编码请求体, 并追加 Content-Type 及 Accept 头
+func encodeRequest(data any, options []ClientOption) ([]byte, codec.Codec, []ClientOption, error) {
+	c, contentType, err := requestCodec(options)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	body, err := c.Marshal(data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	options = append(options, WithHTTPHeader("Content-Type", contentType))
+	if accept := codec.ContentType(c); accept != "" {
+		options = append(options, WithHTTPHeader("Accept", accept))
+	}
+	return body, c, options, nil
+}
+
+// decodeResponse 根据响应的 Content-Type 解码, 未设置时使用请求体编码
+func decodeResponse(header http.Header, body []byte, reqCodec codec.Codec, reply any) error {
+	if reply == nil || len(body) == 0 {
+		return nil
+	}
+	c := reqCodec
+	if ct := header.Get("Content-Type"); ct != "" {
+		if c = codec.GetCodecByContentType(ct); c == nil {
+			return ErrUnsupportedMediaType
+		}
+	}
+	return c.Unmarshal(body, reply)
 }
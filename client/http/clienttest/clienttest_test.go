@@ -0,0 +1,124 @@
+package clienttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	httpclient "github.com/binbinly/pkg/client/http"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeT struct {
+	errs []string
+}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errs = append(f.errs, fmt.Sprintf(format, args...))
+}
+
+func TestMock(t *testing.T) {
+	m := NewMock()
+	m.On(http.MethodGet, "https://api.example.com/users/*").
+		MatchHeader("X-Token", "t1").
+		ReplyJSON(http.StatusOK, map[string]string{"name": "foo"}).Once()
+	m.On(http.MethodPost, "/users").
+		MatchJSON(map[string]any{"name": "bar", "age": 1}).
+		ReplyJSON(http.StatusCreated, map[string]int{"id": 2})
+	m.On(http.MethodGet, "/users").MatchQuery("page", "2").Reply(http.StatusNotFound, []byte("none"))
+	m.On(http.MethodDelete, "/users/*").ReplyError(errors.New("boom")).Maybe()
+
+	client := m.Client()
+	ctx := context.Background()
+
+	resp, err := client.Get(ctx, "https://api.example.com/users/1", httpclient.WithHTTPHeader("X-Token", "t1"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"foo"}`, string(resp))
+
+	// 超过调用次数后不再匹配
+	_, err = client.Get(ctx, "https://api.example.com/users/1", httpclient.WithHTTPHeader("X-Token", "t1"))
+	assert.ErrorIs(t, err, ErrNoRoute)
+
+	var reply struct {
+		ID int `json:"id"`
+	}
+	assert.NoError(t, client.PostData(ctx, "https://api.example.com/users", map[string]any{"age": 1, "name": "bar"}, &reply))
+	assert.Equal(t, 2, reply.ID)
+
+	_, err = client.Get(ctx, "https://api.example.com/users", httpclient.WithQuery("page", "2"))
+	var httpErr *httpclient.HTTPError
+	if assert.ErrorAs(t, err, &httpErr) {
+		assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+	}
+	assert.Equal(t, 1, m.Calls(http.MethodGet, "/users"))
+
+	ft := &fakeT{}
+	assert.False(t, m.AssertExpectations(ft))
+	assert.Equal(t, []string{"clienttest: unexpected request GET https://api.example.com/users/1"}, ft.errs)
+
+	m2 := NewMock()
+	m2.On(http.MethodGet, "/a").Times(2)
+	m2.On(http.MethodGet, "/b")
+	_, _ = m2.Client().Get(ctx, "http://x/a")
+	ft = &fakeT{}
+	assert.False(t, m2.AssertExpectations(ft))
+	assert.Equal(t, []string{"clienttest: GET /a expected 2 calls, got 1", "clienttest: GET /b expected to be called"}, ft.errs)
+}
+
+func TestRecorder(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = fmt.Fprintf(w, "%s %s #%d", r.Method, r.URL.Path, calls)
+	}))
+	fixture := filepath.Join(t.TempDir(), "testdata", "fixture.json")
+	ctx := context.Background()
+
+	rec, err := NewRecorder(fixture, ModeAuto)
+	assert.NoError(t, err)
+	assert.Equal(t, ModeRecord, rec.Mode())
+	client := rec.Client()
+	b1, err := client.Get(ctx, srv.URL+"/a")
+	assert.NoError(t, err)
+	b2, err := client.PostJSON(ctx, srv.URL+"/b", []byte(`{"a":1}`))
+	assert.NoError(t, err)
+	b3, err := client.Get(ctx, srv.URL+"/a")
+	assert.NoError(t, err)
+	assert.NoError(t, rec.Save())
+	assert.Len(t, rec.Interactions(), 3)
+	srv.Close()
+
+	// 离线回放
+	rec, err = NewRecorder(fixture, ModeAuto)
+	assert.NoError(t, err)
+	assert.Equal(t, ModeReplay, rec.Mode())
+	client = rec.Client()
+	r1, err := client.Get(ctx, srv.URL+"/a")
+	assert.NoError(t, err)
+	r3, err := client.Get(ctx, srv.URL+"/a")
+	assert.NoError(t, err)
+	r2, err := client.PostJSON(ctx, srv.URL+"/b", []byte(`{"a":1}`))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{b1, b2, b3}, [][]byte{r1, r2, r3})
+
+	_, err = client.PostJSON(ctx, srv.URL+"/b", []byte(`{"a":2}`))
+	assert.ErrorIs(t, err, ErrNoInteraction)
+
+	_, err = NewRecorder(filepath.Join(t.TempDir(), "none.json"), ModeReplay)
+	assert.Error(t, err)
+}
+
+func TestBody(t *testing.T) {
+	for _, b := range []Body{Body("text"), Body{0xff, 0x00, 0xfe}} {
+		data, err := b.MarshalJSON()
+		assert.NoError(t, err)
+		var got Body
+		assert.NoError(t, got.UnmarshalJSON(data))
+		assert.Equal(t, b, got)
+	}
+}
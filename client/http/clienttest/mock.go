@@ -0,0 +1,297 @@
+// Package clienttest 提供 client/http 的测试工具: 可编程的 Mock 及录制回放 Recorder
+package clienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	httpclient "github.com/binbinly/pkg/client/http"
+)
+
+var (
+	// ErrNoRoute Mock 没有匹配请求的规则
+	ErrNoRoute = errors.New("clienttest: no route matched")
+	// ErrNoInteraction 回放时没有匹配请求的录制记录
+	ErrNoInteraction = errors.New("clienttest: no recorded interaction matched")
+)
+
+// TestingT 测试接口, *testing.T 实现了该接口
+type TestingT interface {
+	Errorf(format string, args ...any)
+}
+
+// Mock 可编程的 http 传输层, 按规则匹配请求并返回预设响应
+//
+//	m := clienttest.NewMock()
+//	m.On(http.MethodGet, "https://api.example.com/users/*").ReplyJSON(http.StatusOK, user).Times(1)
+//	client := m.Client()
+//	...
+//	m.AssertExpectations(t)
+type Mock struct {
+	mu        sync.Mutex
+	routes    []*Route
+	unmatched []string
+}
+
+// NewMock 创建 Mock
+func NewMock() *Mock {
+	return &Mock{}
+}
+
+// On 添加规则, pattern 使用 path.Match 语法匹配 scheme://host/path, 以 / 开头时只匹配 path
+// 多个规则同时命中时使用最先添加且未超过调用次数的规则
+func (m *Mock) On(method, pattern string) *Route {
+	r := &Route{
+		method:  strings.ToUpper(method),
+		pattern: pattern,
+		header:  http.Header{},
+		query:   map[string]string{},
+		code:    http.StatusOK,
+		times:   -1,
+	}
+
+	m.mu.Lock()
+	m.routes = append(m.routes, r)
+	m.mu.Unlock()
+	return r
+}
+
+// Client 创建使用 Mock 作为传输层的 http client
+func (m *Mock) Client(opts ...httpclient.Option) httpclient.Client {
+	return httpclient.NewRawClientWithOptions(append(opts, httpclient.WithTransport(m))...)
+}
+
+// RoundTrip implements http.RoundTripper
+func (m *Mock) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range m.routes {
+		if r.exhausted() || !r.match(req, body) {
+			continue
+		}
+		r.calls++
+		if r.err != nil {
+			return nil, r.err
+		}
+		return r.response(req), nil
+	}
+
+	desc := req.Method + " " + req.URL.String()
+	m.unmatched = append(m.unmatched, desc)
+	return nil, fmt.Errorf("%w: %s", ErrNoRoute, desc)
+}
+
+// AssertExpectations 断言所有规则的调用次数符合预期且没有未匹配的请求
+func (m *Mock) AssertExpectations(t TestingT) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := true
+	for _, r := range m.routes {
+		switch {
+		case r.times >= 0 && r.calls != r.times:
+			t.Errorf("clienttest: %s %s expected %d calls, got %d", r.method, r.pattern, r.times, r.calls)
+			ok = false
+		case r.times < 0 && !r.maybe && r.calls == 0:
+			t.Errorf("clienttest: %s %s expected to be called", r.method, r.pattern)
+			ok = false
+		}
+	}
+	for _, u := range m.unmatched {
+		t.Errorf("clienttest: unexpected request %s", u)
+		ok = false
+	}
+	return ok
+}
+
+// Calls 获取命中 method 及 pattern 规则的总调用次数
+func (m *Mock) Calls(method, pattern string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int
+	for _, r := range m.routes {
+		if r.method == strings.ToUpper(method) && r.pattern == pattern {
+			n += r.calls
+		}
+	}
+	return n
+}
+
+// Route 请求匹配规则及预设响应
+type Route struct {
+	method  string
+	pattern string
+	header  http.Header
+	query   map[string]string
+	body    func(body []byte) bool
+
+	code       int
+	respHeader http.Header
+	respBody   []byte
+	err        error
+
+	times int
+	maybe bool
+	calls int
+}
+
+// MatchHeader 请求头需要匹配
+func (r *Route) MatchHeader(key, value string) *Route {
+	r.header.Set(key, value)
+	return r
+}
+
+// MatchQuery 请求参数需要匹配
+func (r *Route) MatchQuery(key, value string) *Route {
+	r.query[key] = value
+	return r
+}
+
+// MatchBody 请求体需要满足 fn
+func (r *Route) MatchBody(fn func(body []byte) bool) *Route {
+	r.body = fn
+	return r
+}
+
+// MatchBodyString 请求体需要与 s 相等
+func (r *Route) MatchBodyString(s string) *Route {
+	return r.MatchBody(func(body []byte) bool {
+		return string(body) == s
+	})
+}
+
+// MatchJSON 请求体需要与 v 编码后的 json 等价
+func (r *Route) MatchJSON(v any) *Route {
+	want, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return r.MatchBody(func(body []byte) bool {
+		var a, b any
+		if json.Unmarshal(want, &a) != nil || json.Unmarshal(body, &b) != nil {
+			return false
+		}
+		x, _ := json.Marshal(a)
+		y, _ := json.Marshal(b)
+		return bytes.Equal(x, y)
+	})
+}
+
+// Reply 预设响应
+func (r *Route) Reply(code int, body []byte) *Route {
+	r.code = code
+	r.respBody = body
+	return r
+}
+
+// ReplyJSON 预设 json 响应
+func (r *Route) ReplyJSON(code int, v any) *Route {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return r.ReplyHeader("Content-Type", "application/json; charset=utf-8").Reply(code, body)
+}
+
+// ReplyHeader 预设响应头
+func (r *Route) ReplyHeader(key, value string) *Route {
+	if r.respHeader == nil {
+		r.respHeader = http.Header{}
+	}
+	r.respHeader.Add(key, value)
+	return r
+}
+
+// ReplyError 返回传输层错误
+func (r *Route) ReplyError(err error) *Route {
+	r.err = err
+	return r
+}
+
+// Times 规则期望被调用 n 次, 超过后不再匹配
+func (r *Route) Times(n int) *Route {
+	r.times = n
+	return r
+}
+
+// Once 规则期望被调用 1 次
+func (r *Route) Once() *Route {
+	return r.Times(1)
+}
+
+// Maybe 规则可以不被调用
+func (r *Route) Maybe() *Route {
+	r.maybe = true
+	return r
+}
+
+func (r *Route) exhausted() bool {
+	return r.times >= 0 && r.calls >= r.times
+}
+
+func (r *Route) match(req *http.Request, body []byte) bool {
+	if r.method != "" && r.method != req.Method {
+		return false
+	}
+	target := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+	if strings.HasPrefix(r.pattern, "/") {
+		target = req.URL.Path
+	}
+	if ok, _ := path.Match(r.pattern, target); !ok {
+		return false
+	}
+	for k := range r.header {
+		if req.Header.Get(k) != r.header.Get(k) {
+			return false
+		}
+	}
+	q := req.URL.Query()
+	for k, v := range r.query {
+		if q.Get(k) != v {
+			return false
+		}
+	}
+	return r.body == nil || r.body(body)
+}
+
+func (r *Route) response(req *http.Request) *http.Response {
+	header := r.respHeader.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(r.code) + " " + http.StatusText(r.code),
+		StatusCode:    r.code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.respBody)),
+		ContentLength: int64(len(r.respBody)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,247 @@
+package clienttest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	httpclient "github.com/binbinly/pkg/client/http"
+)
+
+// Mode 录制回放模式
+type Mode int
+
+const (
+	// ModeReplay 只从录制文件回放, 未匹配的请求返回 ErrNoInteraction
+	ModeReplay Mode = iota
+	// ModeRecord 发送真实请求并录制, Save 时覆盖录制文件
+	ModeRecord
+	// ModeAuto 录制文件存在时回放, 否则录制
+	ModeAuto
+)
+
+// Interaction 一次录制的请求及响应
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest 录制的请求, 请求头只保留 Content-Type 以免泄露凭证
+type RecordedRequest struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        Body   `json:"body,omitempty"`
+}
+
+// RecordedResponse 录制的响应
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body,omitempty"`
+}
+
+// Body 录制的消息体, utf8 文本按字符串保存, 其他内容使用 base64 编码
+type Body []byte
+
+// MarshalJSON implements json.Marshaler
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = Body(s)
+		return nil
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	raw, err := base64.StdEncoding.DecodeString(m["base64"])
+	if err != nil {
+		return err
+	}
+	*b = raw
+	return nil
+}
+
+// Recorder 录制真实请求到文件, 并在离线测试中回放
+//
+//	rec, _ := clienttest.NewRecorder("testdata/users.json", clienttest.ModeAuto)
+//	defer rec.Save()
+//	client := rec.Client()
+type Recorder struct {
+	mu           sync.Mutex
+	fixture      string
+	mode         Mode
+	next         http.RoundTripper
+	interactions []*Interaction
+	used         []bool
+}
+
+// NewRecorder 创建录制回放器, 回放模式下加载录制文件
+func NewRecorder(fixture string, mode Mode) (*Recorder, error) {
+	r := &Recorder{fixture: fixture, mode: mode, next: http.DefaultTransport}
+	if mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(fixture); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+	if r.mode != ModeReplay {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &r.interactions); err != nil {
+		return nil, fmt.Errorf("clienttest: parse fixture %s: %w", fixture, err)
+	}
+	r.used = make([]bool, len(r.interactions))
+	return r, nil
+}
+
+// Mode 当前模式, ModeAuto 会解析为 ModeReplay 或 ModeRecord
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client 创建使用 Recorder 作为传输层的 http client
+func (r *Recorder) Client(opts ...httpclient.Option) httpclient.Client {
+	return httpclient.NewRawClientWithOptions(append(opts, httpclient.WithTransport(r))...)
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, body)
+	}
+	return r.record(req, body)
+}
+
+// replay 回放, 相同请求按录制顺序依次返回, 用完后重复最后一次
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := -1
+	for i, it := range r.interactions {
+		if it.Request.Method != req.Method || it.Request.URL != req.URL.String() || !bytes.Equal(it.Request.Body, body) {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return it.Response.response(req), nil
+		}
+		last = i
+	}
+	if last >= 0 {
+		return r.interactions[last].Response.response(req), nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL)
+}
+
+// record 发送真实请求并录制
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.ContentLength = int64(len(body))
+	}
+
+	resp, err := r.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	r.mu.Lock()
+	r.interactions = append(r.interactions, &Interaction{
+		Request: RecordedRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			ContentType: req.Header.Get("Content-Type"),
+			Body:        body,
+		},
+		Response: RecordedResponse{StatusCode: resp.StatusCode, Header: header, Body: respBody},
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Save 录制模式下保存录制文件, 回放模式下不做任何操作
+func (r *Recorder) Save() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.fixture), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.fixture, data, 0o644)
+}
+
+// Interactions 获取所有录制记录
+func (r *Recorder) Interactions() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*Interaction(nil), r.interactions...)
+}
+
+func (r *RecordedResponse) response(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(r.StatusCode) + " " + http.StatusText(r.StatusCode),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
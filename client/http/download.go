@@ -0,0 +1,285 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/binbinly/pkg/util/xhash"
+)
+
+// maxResumeAttempts 下载过程中连接中断时最多续传次数
+const maxResumeAttempts = 3
+
+var (
+	// ErrChecksumMismatch 下载内容的 md5 与期望值不一致
+	ErrChecksumMismatch = errors.New("http: checksum mismatch")
+	// ErrRangeNotSupported 服务端不支持 Range 请求且 writer 无法截断重写
+	ErrRangeNotSupported = errors.New("http: range request not supported")
+)
+
+// ProgressFunc 传输进度回调, total 未知时为 -1
+type ProgressFunc func(written, total int64)
+
+// WithProgress specifies the progress callback of upload and download.
+func WithProgress(fn ProgressFunc) ClientOption {
+	return func(s *httpSettings) {
+		s.progress = fn
+	}
+}
+
+// WithChecksum specifies the md5 hex of the downloaded content to verify.
+func WithChecksum(md5 string) ClientOption {
+	return func(s *httpSettings) {
+		s.checksum = strings.ToLower(md5)
+	}
+}
+
+// streamer 发送请求并返回未读取的响应, 调用方负责关闭响应体
+type streamer interface {
+	stream(ctx context.Context, method, reqURL string, body io.Reader, options ...ClientOption) (*http.Response, error)
+}
+
+// progress 统计已传输字节数并回调
+type progress struct {
+	written int64
+	total   int64
+	fn      ProgressFunc
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if p.fn != nil {
+		p.fn(p.written, p.total)
+	}
+	return len(b), nil
+}
+
+// readResponse 读取并关闭响应体
+func readResponse(resp *http.Response) (*Response, error) {
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return newResponse(resp.Request.Method, resp.Request.URL.String(), resp.StatusCode, resp.Header, b)
+}
+
+// upload 流式上传表单文件, 请求体无法重放因此不会重试
+func upload(ctx context.Context, s streamer, reqURL string, form UploadForm, options []ClientOption) ([]byte, error) {
+	settings := applySettings(options)
+
+	body, contentType, err := multipartBody(form, settings.progress)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	options = append(options, WithHTTPHeader("Content-Type", contentType))
+	resp, err := s.stream(ctx, http.MethodPost, reqURL, body, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseBody(readResponse(resp))
+}
+
+// downloader 下载状态
+type downloader struct {
+	s       streamer
+	reqURL  string
+	w       io.Writer
+	dst     io.Writer
+	options []ClientOption
+	p       *progress
+	ranged  bool // 服务端是否支持 Range
+}
+
+// download 下载到 w, w 实现 io.Seeker 时(如 *os.File)从已有内容末尾通过 Range 续传,
+// 传输中断时自动续传, 服务端不支持 Range 时需要 w 实现 Truncate 以便从头下载
+func download(ctx context.Context, s streamer, reqURL string, w io.Writer, options []ClientOption) (int64, error) {
+	settings := applySettings(options)
+
+	var offset int64
+	if seeker, ok := w.(io.Seeker); ok {
+		n, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0, err
+		}
+		offset = n
+	}
+
+	d := &downloader{
+		s:       s,
+		reqURL:  reqURL,
+		w:       w,
+		dst:     w,
+		options: options[:len(options):len(options)],
+		p:       &progress{written: offset, total: -1, fn: settings.progress},
+	}
+
+	// 无法回读已写入内容时, 边下载边计算 md5
+	var (
+		pw  *io.PipeWriter
+		sum chan checksumResult
+	)
+	if _, ok := w.(io.ReadSeeker); settings.checksum != "" && !ok {
+		var pr *io.PipeReader
+		pr, pw = io.Pipe()
+		sum = make(chan checksumResult, 1)
+		go func() {
+			got, err := xhash.MD5Reader(pr)
+			sum <- checksumResult{sum: got, err: err}
+		}()
+		d.dst = io.MultiWriter(w, pw)
+	}
+
+	err := d.run(ctx)
+	if pw != nil {
+		pw.CloseWithError(err)
+	}
+	if err != nil {
+		return d.p.written - offset, err
+	}
+
+	if settings.checksum != "" {
+		var got string
+		if sum != nil {
+			res := <-sum
+			got, err = res.sum, res.err
+		} else {
+			got, err = readChecksum(d.w.(io.ReadSeeker))
+		}
+		if err != nil {
+			return d.p.written - offset, err
+		}
+		if got != settings.checksum {
+			return d.p.written - offset, fmt.Errorf("%w: want %s, got %s", ErrChecksumMismatch, settings.checksum, got)
+		}
+	}
+
+	return d.p.written - offset, nil
+}
+
+// run 执行下载, 传输中断且服务端支持 Range 时续传
+func (d *downloader) run(ctx context.Context) error {
+	for attempt := 1; ; attempt++ {
+		err := d.fetch(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var re *readError
+		if !errors.As(err, &re) {
+			return err
+		}
+		if !d.ranged || attempt >= maxResumeAttempts || ctx.Err() != nil || !IsRetryableError(re.err) {
+			return re.err
+		}
+	}
+}
+
+// fetch 发送一次下载请求, 已下载部分通过 Range 跳过
+func (d *downloader) fetch(ctx context.Context) error {
+	offset := d.p.written
+	options := d.options
+	if offset > 0 {
+		options = append(options, WithHTTPHeader("Range", fmt.Sprintf("bytes=%d-", offset)))
+	}
+
+	resp, err := d.s.stream(ctx, http.MethodGet, d.reqURL, nil, options...)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch code := resp.StatusCode; {
+	case code == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// 已有内容不小于远程文件, 视为已下载完成
+		return nil
+	case code == http.StatusPartialContent:
+		d.ranged = true
+		if resp.ContentLength >= 0 {
+			d.p.total = offset + resp.ContentLength
+		}
+	case code >= http.StatusOK && code < http.StatusMultipleChoices:
+		d.ranged = resp.Header.Get("Accept-Ranges") == "bytes"
+		if offset > 0 {
+			// 服务端忽略了 Range, 从头下载
+			if err = d.reset(); err != nil {
+				return err
+			}
+		}
+		d.p.total = resp.ContentLength
+	default:
+		_, err = readResponse(resp)
+		return err
+	}
+
+	_, err = io.Copy(io.MultiWriter(d.dst, d.p), &errReader{r: resp.Body})
+	return err
+}
+
+// reset 截断 writer 重新下载
+func (d *downloader) reset() error {
+	t, ok := d.w.(interface {
+		io.Seeker
+		Truncate(size int64) error
+	})
+	if !ok {
+		return ErrRangeNotSupported
+	}
+	if err := t.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := t.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	d.p.written = 0
+	return nil
+}
+
+// readChecksum 回读 writer 的全部内容计算 md5
+func readChecksum(rs io.ReadSeeker) (string, error) {
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	sum, err := xhash.MD5Reader(rs)
+	if err != nil {
+		return "", err
+	}
+	_, err = rs.Seek(0, io.SeekEnd)
+	return sum, err
+}
+
+type checksumResult struct {
+	sum string
+	err error
+}
+
+// readError 读取响应体时发生的错误, 可以续传
+type readError struct {
+	err error
+}
+
+func (e *readError) Error() string { return e.err.Error() }
+
+func (e *readError) Unwrap() error { return e.err }
+
+// errReader 区分读取响应体与写入 writer 的错误
+type errReader struct {
+	r io.Reader
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		return n, &readError{err: err}
+	}
+	return n, err
+}
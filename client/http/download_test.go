@@ -0,0 +1,197 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		files := map[string]string{}
+		for field, fhs := range r.MultipartForm.File {
+			f, _ := fhs[0].Open()
+			b, _ := io.ReadAll(f)
+			f.Close()
+			files[field] = fhs[0].Filename + ":" + string(b)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"files":  files,
+			"title":  r.FormValue("title"),
+			"length": r.ContentLength,
+		})
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "a.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("hello"), 0o644))
+
+	for name, c := range map[string]Client{"raw": NewRawClient(), "resty": NewRestyClient()} {
+		t.Run(name, func(t *testing.T) {
+			var last, total int64
+			form := NewUploadForm("media", path,
+				WithExtraField("title", "TITLE"),
+				WithFileReader("extra", "b.txt", strings.NewReader("world!"), 6),
+			)
+			b, err := c.Upload(context.Background(), srv.URL, form, WithProgress(func(written, size int64) {
+				last, total = written, size
+			}))
+			assert.NoError(t, err)
+
+			var reply struct {
+				Files  map[string]string `json:"files"`
+				Title  string            `json:"title"`
+				Length int64             `json:"length"`
+			}
+			assert.NoError(t, json.Unmarshal(b, &reply))
+			assert.Equal(t, "a.txt:hello", reply.Files["media"])
+			assert.Equal(t, "b.txt:world!", reply.Files["extra"])
+			assert.Equal(t, "TITLE", reply.Title)
+			// 流式上传使用 chunked 编码
+			assert.Equal(t, int64(-1), reply.Length)
+			assert.Equal(t, int64(11), last)
+			assert.Equal(t, int64(11), total)
+		})
+	}
+
+	_, err := NewRawClient().Upload(context.Background(), srv.URL, NewUploadForm("media", filepath.Join(t.TempDir(), "none")))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// 只实现 UploadForm 的表单按单个文件上传
+	b, err := NewRestyClient().Upload(context.Background(), srv.URL, bufferForm{})
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"media":"c.txt:buffer"`)
+	assert.Contains(t, string(b), `"title":"T"`)
+}
+
+// bufferForm 未实现 MultiFileForm 的表单
+type bufferForm struct{}
+
+func (bufferForm) FieldName() string              { return "media" }
+func (bufferForm) FileName() string               { return "c.txt" }
+func (bufferForm) ExtraFields() map[string]string { return map[string]string{"title": "T"} }
+func (bufferForm) Buffer() ([]byte, error)        { return []byte("buffer"), nil }
+
+func md5Hex(b []byte) string {
+	sum := md5.Sum(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10<<10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	for name, c := range map[string]Client{"raw": NewRawClient(), "resty": NewRestyClient()} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var last int64
+			n, err := c.Download(context.Background(), srv.URL, &buf, WithChecksum(md5Hex(content)),
+				WithProgress(func(written, total int64) {
+					last = written
+					assert.Equal(t, int64(len(content)), total)
+				}))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(content)), n)
+			assert.Equal(t, int64(len(content)), last)
+			assert.Equal(t, content, buf.Bytes())
+
+			// 续传已有文件
+			f, err := os.Create(filepath.Join(t.TempDir(), "file"))
+			assert.NoError(t, err)
+			defer f.Close()
+			_, _ = f.Write(content[:1000])
+			n, err = c.Download(context.Background(), srv.URL, f, WithChecksum(md5Hex(content)))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(content)-1000), n)
+
+			// 已下载完成
+			n, err = c.Download(context.Background(), srv.URL, f)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(0), n)
+
+			_, err = c.Download(context.Background(), srv.URL, io.Discard, WithChecksum("bad"))
+			assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+			var httpErr *HTTPError
+			_, err = c.Download(context.Background(), srv.URL, &buf, WithHTTPHeader("Range", "bytes=a"))
+			if assert.True(t, errors.As(err, &httpErr)) {
+				assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, httpErr.StatusCode)
+			}
+		})
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghij"), 10<<10)
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// 首次请求只返回一半内容后断开连接
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", "102400")
+			_, _ = w.Write(content[:len(content)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	n, err := NewRawClient().Download(context.Background(), srv.URL, &buf, WithChecksum(md5Hex(content)))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), n)
+	assert.Equal(t, content, buf.Bytes())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestDownloadIdleTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "5")
+		w.WriteHeader(http.StatusOK)
+		gap := 30 * time.Millisecond
+		if r.URL.Path == "/stall" {
+			gap = 300 * time.Millisecond
+		}
+		for i := 0; i < 5; i++ {
+			_, _ = w.Write([]byte{'a'})
+			w.(http.Flusher).Flush()
+			time.Sleep(gap)
+		}
+	}))
+	defer srv.Close()
+
+	for name, c := range map[string]Client{"raw": NewRawClient(), "resty": NewRestyClient()} {
+		t.Run(name, func(t *testing.T) {
+			// 总耗时超过 timeout, 但持续有数据时不会超时
+			var buf bytes.Buffer
+			n, err := c.Download(context.Background(), srv.URL, &buf, WithHTTPTimeout(100*time.Millisecond))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(5), n)
+
+			_, err = c.Download(context.Background(), srv.URL+"/stall", io.Discard, WithHTTPTimeout(100*time.Millisecond))
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		})
+	}
+}
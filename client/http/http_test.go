@@ -1,9 +1,16 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
+	"github.com/binbinly/pkg/codec"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,3 +58,91 @@ func TestUploadOption(t *testing.T) {
 		"introduction": "INTRODUCTION",
 	}, upload.extraFields)
 }
+
+func TestPostData(t *testing.T) {
+	type user struct {
+		Name string `json:"name" msgpack:"name"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := codec.GetCodecByContentType(r.Header.Get("Content-Type"))
+		in := &user{}
+		body, _ := io.ReadAll(r.Body)
+		if err := c.Unmarshal(body, in); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		buf, _ := c.Marshal(&user{Name: "hello " + in.Name})
+		w.Header().Set("Content-Type", r.Header.Get("Accept"))
+		_, _ = w.Write(buf)
+	}))
+	defer srv.Close()
+
+	for _, client := range []Client{NewRawClient(), NewRestyClient()} {
+		for _, contentType := range []string{codec.MIMEJSON, codec.MIMEMsgpack} {
+			reply := &user{}
+			err := client.PostData(context.Background(), srv.URL, &user{Name: "test"}, reply,
+				WithContentType(contentType))
+			assert.Nil(t, err)
+			assert.Equal(t, "hello test", reply.Name)
+		}
+	}
+}
+
+func TestClientMethods(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":10003,"msg":"Not found"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"method": r.Method, "query": r.URL.RawQuery, "body": string(body)})
+	}))
+	defer srv.Close()
+
+	type reply struct {
+		Method string `json:"method"`
+		Query  string `json:"query"`
+		Body   string `json:"body"`
+	}
+	ctx := context.Background()
+	for _, client := range []Client{NewRawClient(), NewRestyClient()} {
+		b, err := client.Put(ctx, srv.URL, []byte("put"))
+		assert.Nil(t, err)
+		assert.JSONEq(t, `{"method":"PUT","query":"","body":"put"}`, string(b))
+
+		b, err = client.Patch(ctx, srv.URL, []byte("patch"))
+		assert.Nil(t, err)
+		assert.JSONEq(t, `{"method":"PATCH","query":"","body":"patch"}`, string(b))
+
+		b, err = client.Delete(ctx, srv.URL+"?id=1", WithQuery("name", "a b"))
+		assert.Nil(t, err)
+		assert.JSONEq(t, `{"method":"DELETE","query":"id=1&name=a+b","body":""}`, string(b))
+
+		resp, err := client.Head(ctx, srv.URL)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "HEAD", resp.Header.Get("X-Method"))
+
+		out, err := DoJSON[reply](ctx, client, http.MethodPost, srv.URL, map[string]string{"k": "v"},
+			WithQueryParams(url.Values{"page": {"1"}}))
+		assert.Nil(t, err)
+		assert.Equal(t, "POST", out.Method)
+		assert.Equal(t, "page=1", out.Query)
+
+		resp, err = client.Do(ctx, http.MethodGet, srv.URL, nil, WithQuery("fail", "1"))
+		var httpErr *HTTPError
+		assert.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+		assert.Equal(t, `{"code":10003,"msg":"Not found"}`, string(httpErr.Body))
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestBuildURL(t *testing.T) {
+	u, err := BuildURL("https://example.com/path?a=1", url.Values{"b": {"2"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.com/path?a=1&b=2", u)
+}
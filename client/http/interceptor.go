@@ -0,0 +1,235 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/binbinly/pkg/signature"
+	"github.com/binbinly/pkg/transport"
+	"github.com/rs/xid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// HeaderRequestID 请求id头
+	HeaderRequestID = transport.HeaderRequestID
+	// HeaderSignature 签名头
+	HeaderSignature = "X-Signature"
+	// HeaderTimestamp 签名时间戳头
+	HeaderTimestamp = "X-Timestamp"
+
+	tracerName = "github.com/binbinly/pkg/client/http"
+)
+
+// Doer 执行 http 请求
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc 函数形式的 Doer
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do 执行请求
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Interceptor 请求拦截器, 包装 next 实现请求前后的通用逻辑
+type Interceptor func(next Doer) Doer
+
+// Chain 组合多个拦截器, 第一个拦截器在最外层
+func Chain(interceptors ...Interceptor) Interceptor {
+	return func(next Doer) Doer {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			next = interceptors[i](next)
+		}
+		return next
+	}
+}
+
+// WithInterceptors specifies the interceptors to http client, raw and resty client share the same chain.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(o *options) {
+		o.interceptors = append(o.interceptors, interceptors...)
+	}
+}
+
+// interceptorTransport 将拦截器链适配为 http.RoundTripper
+type interceptorTransport struct {
+	doer Doer
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *interceptorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.doer.Do(req)
+}
+
+// newInterceptorTransport 使用拦截器包装 rt
+func newInterceptorTransport(rt http.RoundTripper, interceptors []Interceptor) http.RoundTripper {
+	return &interceptorTransport{doer: Chain(interceptors...)(DoerFunc(rt.RoundTrip))}
+}
+
+// cloneRequest RoundTripper 不应修改原请求, 修改头之前先复制
+func cloneRequest(req *http.Request) *http.Request {
+	r := req.Clone(req.Context())
+	r.Body = req.Body
+	return r
+}
+
+// WithRequestID 将请求id放入 context, 由 RequestIDInterceptor 透传, 同 transport.WithRequestID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return transport.WithRequestID(ctx, id)
+}
+
+// RequestIDFromContext 从 context 获取请求id, 同 transport.RequestIDFromContext
+func RequestIDFromContext(ctx context.Context) string {
+	return transport.RequestIDFromContext(ctx)
+}
+
+// RequestIDInterceptor 透传请求id, context 中没有时生成新的id
+func RequestIDInterceptor() Interceptor {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(HeaderRequestID) != "" {
+				return next.Do(req)
+			}
+			id := RequestIDFromContext(req.Context())
+			if id == "" {
+				id = xid.New().String()
+			}
+			req = cloneRequest(req)
+			req.Header.Set(HeaderRequestID, id)
+			return next.Do(req)
+		})
+	}
+}
+
+// SignInterceptor 使用 signature 对请求签名, 签名参数为 query 参数及 method、path,
+// 有请求体时 body 参数为请求体的 sha256 十六进制摘要, 签名写入 X-Signature 头, 时间戳写入 X-Timestamp 头
+func SignInterceptor(sign signature.Signature) Interceptor {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req, digest, err := bodyDigest(req)
+			if err != nil {
+				closeBody(req)
+				return nil, err
+			}
+			params := req.URL.Query()
+			params.Set("method", req.Method)
+			params.Set("path", req.URL.Path)
+			if digest != "" {
+				params.Set("body", digest)
+			}
+			auth, ts, err := sign.Generate(params)
+			if err != nil {
+				closeBody(req)
+				return nil, err
+			}
+			req = cloneRequest(req)
+			req.Header.Set(HeaderSignature, auth)
+			req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+			return next.Do(req)
+		})
+	}
+}
+
+// bodyDigest 请求体的 sha256 摘要, 优先通过 GetBody 读取副本,
+// 否则读取整个请求体并替换为内存副本, 没有请求体时摘要为空
+func bodyDigest(req *http.Request) (*http.Request, string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, "", nil
+	}
+
+	h := sha256.New()
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return req, "", err
+		}
+		defer rc.Close()
+		if _, err = io.Copy(h, rc); err != nil {
+			return req, "", err
+		}
+		return req, hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return req, "", err
+	}
+	h.Write(buf)
+	req = cloneRequest(req)
+	req.Body = io.NopCloser(bytes.NewReader(buf))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf)), nil
+	}
+	return req, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// TokenSource 获取认证 token
+type TokenSource func(ctx context.Context) (string, error)
+
+// AuthInterceptor 注入 Authorization: Bearer token
+func AuthInterceptor(source TokenSource) Interceptor {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			token, err := source(req.Context())
+			if err != nil {
+				closeBody(req)
+				return nil, err
+			}
+			req = cloneRequest(req)
+			req.Header.Set("Authorization", "Bearer "+token)
+			return next.Do(req)
+		})
+	}
+}
+
+// TraceInterceptor 为请求创建 OpenTelemetry span 并注入传播头
+func TraceInterceptor() Interceptor {
+	tracer := otel.Tracer(tracerName)
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, span := tracer.Start(req.Context(), "HTTP "+req.Method,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("http.request.method", req.Method),
+					attribute.String("url.full", req.URL.String()),
+					attribute.String("server.address", req.URL.Host),
+				))
+			defer span.End()
+
+			req = req.Clone(ctx)
+			otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+			resp, err := next.Do(req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return resp, err
+			}
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+			if resp.StatusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+			}
+			return resp, nil
+		})
+	}
+}
+
+// closeBody RoundTripper 出错时需要关闭请求体
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
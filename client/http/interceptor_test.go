@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/binbinly/pkg/signature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterceptors(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	var order []string
+	trace := func(name string) Interceptor {
+		return func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.Do(req)
+			})
+		}
+	}
+	sign := signature.New("sign", "secret", time.Minute)
+	opts := []Option{WithInterceptors(
+		trace("first"),
+		trace("second"),
+		RequestIDInterceptor(),
+		AuthInterceptor(func(ctx context.Context) (string, error) { return "token", nil }),
+		SignInterceptor(sign),
+		LoggingInterceptor(),
+		TraceInterceptor(),
+	)}
+
+	for _, client := range []Client{NewRawClientWithOptions(opts...), NewRestyClient(opts...)} {
+		order = nil
+		ctx := WithRequestID(context.Background(), "req-1")
+		b, err := client.Get(ctx, srv.URL+"/echo?a=1")
+		assert.Nil(t, err)
+		assert.Equal(t, "ok", string(b))
+		assert.Equal(t, []string{"first", "second"}, order)
+		assert.Equal(t, "req-1", header.Get(HeaderRequestID))
+		assert.Equal(t, "Bearer token", header.Get("Authorization"))
+
+		ts, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+		assert.Nil(t, err)
+		params := url.Values{"a": {"1"}, "method": {http.MethodGet}, "path": {"/echo"}}
+		ok, err := sign.Verify(header.Get(HeaderSignature), ts, params)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+
+		// 未设置请求id时自动生成
+		_, err = client.Get(context.Background(), srv.URL)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, header.Get(HeaderRequestID))
+	}
+}
+
+func TestSignInterceptorBody(t *testing.T) {
+	sign := signature.New("sign", "secret", time.Minute)
+	var header http.Header
+	var body string
+	doer := SignInterceptor(sign)(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		header = req.Header
+		b, err := io.ReadAll(req.Body)
+		body = string(b)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, err
+	}))
+
+	sum := sha256.Sum256([]byte(`{"a":1}`))
+	params := url.Values{"method": {http.MethodPost}, "path": {"/echo"}, "body": {hex.EncodeToString(sum[:])}}
+	verify := func() {
+		ts, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+		assert.Nil(t, err)
+		ok, err := sign.Verify(header.Get(HeaderSignature), ts, params)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, `{"a":1}`, body)
+	}
+
+	// 通过 GetBody 读取请求体
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/echo", strings.NewReader(`{"a":1}`))
+	_, err := doer.Do(req)
+	assert.Nil(t, err)
+	verify()
+
+	// 没有 GetBody 时读取请求体后替换
+	req, _ = http.NewRequest(http.MethodPost, "http://example.com/echo", io.NopCloser(strings.NewReader(`{"a":1}`)))
+	assert.Nil(t, req.GetBody)
+	_, err = doer.Do(req)
+	assert.Nil(t, err)
+	verify()
+
+	// 请求体不同时签名不同
+	params.Set("body", "other")
+	ts, _ := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	ok, _ := sign.Verify(header.Get(HeaderSignature), ts, params)
+	assert.False(t, ok)
+}
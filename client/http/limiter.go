@@ -0,0 +1,59 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/binbinly/pkg/limiter"
+)
+
+// ErrLimited 超过限流, 仅在限流器配置 limiter.WithFailFast 时返回
+var ErrLimited = limiter.ErrLimited
+
+// WithLimiter specifies the rate and concurrency limiter to http client,
+// requests are limited by host+path key, each retry attempt is limited too.
+func WithLimiter(g *limiter.Group) Option {
+	return func(o *options) {
+		o.limiter = g
+	}
+}
+
+// limiterTransport 按 host+path 限流, 并发数在响应体关闭后释放
+type limiterTransport struct {
+	next  http.RoundTripper
+	group *limiter.Group
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *limiterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := t.group.Get(req.URL.Host + req.URL.Path)
+	if l == nil {
+		return t.next.RoundTrip(req)
+	}
+	release, err := l.Acquire(req.Context())
+	if err != nil {
+		closeBody(req)
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseBody 关闭响应体时释放许可
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/binbinly/pkg/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	var cur, maxSeen int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&cur, 1)
+		defer atomic.AddInt32(&cur, -1)
+		for {
+			m := atomic.LoadInt32(&maxSeen)
+			if n <= m || atomic.CompareAndSwapInt32(&maxSeen, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	host := srv.Listener.Addr().String()
+
+	g := limiter.NewGroup().
+		Add(host+"/slow", limiter.WithMaxInFlight(2)).
+		Add(host+"/fast", limiter.WithRate(1, 1), limiter.WithFailFast())
+
+	for _, client := range []Client{NewRawClientWithOptions(WithLimiter(g)), NewRestyClient(WithLimiter(g))} {
+		atomic.StoreInt32(&maxSeen, 0)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.Get(context.Background(), srv.URL+"/slow")
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(2), atomic.LoadInt32(&maxSeen))
+		assert.Equal(t, 0, g.Get(host+"/slow").State().InFlight)
+	}
+
+	client := NewRawClientWithOptions(WithLimiter(g))
+	_, err := client.Get(context.Background(), srv.URL+"/fast")
+	assert.NoError(t, err)
+	_, err = client.Get(context.Background(), srv.URL+"/fast")
+	assert.ErrorIs(t, err, ErrLimited)
+	assert.Equal(t, uint64(1), g.States()[host+"/fast"].Rejected)
+}
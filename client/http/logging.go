@@ -0,0 +1,361 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/binbinly/pkg/logger"
+)
+
+const (
+	// redacted 脱敏后的值
+	redacted = "***"
+	// debugBodyLimit WithDebug 时记录的最大消息体长度
+	debugBodyLimit = 4 << 10
+)
+
+var (
+	// defaultRedactHeaders 默认脱敏的请求头及响应头
+	defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", HeaderSignature}
+	// defaultRedactFields 默认脱敏的 json 字段、表单字段及 query 参数, 不区分大小写
+	defaultRedactFields = []string{"password", "passwd", "pwd", "secret", "client_secret", "token",
+		"access_token", "refresh_token", "api_key", "apikey"}
+)
+
+// Outcome 请求结果分类, 用于配置日志级别
+type Outcome int
+
+const (
+	// OutcomeSuccess 状态码 1xx-3xx
+	OutcomeSuccess Outcome = iota
+	// OutcomeClientError 状态码 4xx
+	OutcomeClientError
+	// OutcomeServerError 状态码 5xx
+	OutcomeServerError
+	// OutcomeFailure 请求失败, 没有响应
+	OutcomeFailure
+)
+
+// LogOption configures the logging interceptor
+type LogOption func(*logOptions)
+
+type logOptions struct {
+	bodyLimit     int
+	headers       bool
+	redactHeaders map[string]struct{}
+	redactFields  map[string]struct{}
+	levels        [4]string
+}
+
+// WithLogBody specifies to log request and response bodies up to limit bytes.
+func WithLogBody(limit int) LogOption {
+	return func(o *logOptions) {
+		o.bodyLimit = limit
+	}
+}
+
+// WithLogHeaders specifies to log request and response headers.
+func WithLogHeaders() LogOption {
+	return func(o *logOptions) {
+		o.headers = true
+	}
+}
+
+// WithRedactHeaders specifies extra headers to mask, Authorization and Cookie are masked by default.
+func WithRedactHeaders(names ...string) LogOption {
+	return func(o *logOptions) {
+		for _, n := range names {
+			o.redactHeaders[http.CanonicalHeaderKey(n)] = struct{}{}
+		}
+	}
+}
+
+// WithRedactFields specifies extra json fields, form fields and query params to mask, case-insensitive,
+// password and token are masked by default.
+func WithRedactFields(names ...string) LogOption {
+	return func(o *logOptions) {
+		for _, n := range names {
+			o.redactFields[strings.ToLower(n)] = struct{}{}
+		}
+	}
+}
+
+// WithLogLevel specifies the log level of an outcome,
+// default info for success, warn for client error and error for server error and failure.
+func WithLogLevel(outcome Outcome, level string) LogOption {
+	return func(o *logOptions) {
+		if outcome >= OutcomeSuccess && outcome <= OutcomeFailure {
+			o.levels[outcome] = level
+		}
+	}
+}
+
+func newLogOptions(opts ...LogOption) *logOptions {
+	o := &logOptions{
+		redactHeaders: make(map[string]struct{}),
+		redactFields:  make(map[string]struct{}),
+		levels:        [4]string{logger.InfoLevel, logger.WarnLevel, logger.ErrorLevel, logger.ErrorLevel},
+	}
+	WithRedactHeaders(defaultRedactHeaders...)(o)
+	WithRedactFields(defaultRedactFields...)(o)
+	for _, f := range opts {
+		f(o)
+	}
+	return o
+}
+
+// LoggingInterceptor 通过 logger.Fields 记录结构化日志: 方法、地址、状态码、耗时及收发字节数,
+// 可选记录请求头及消息体, 敏感头及字段会被脱敏; 日志在响应体关闭时输出
+func LoggingInterceptor(opts ...LogOption) Interceptor {
+	o := newLogOptions(opts...)
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			rec := &logRecord{opts: o, req: req, start: time.Now()}
+			if req.Body != nil && req.Body != http.NoBody {
+				req = cloneRequest(req)
+				rec.reqBody = &captureBody{ReadCloser: req.Body, limit: o.bodyLimit}
+				req.Body = rec.reqBody
+			}
+
+			resp, err := next.Do(req)
+			if err != nil {
+				rec.log(nil, err)
+				return resp, err
+			}
+			rec.respBody = &captureBody{ReadCloser: resp.Body, limit: o.bodyLimit, onClose: func() {
+				rec.log(resp, nil)
+			}}
+			resp.Body = rec.respBody
+			return resp, nil
+		})
+	}
+}
+
+// logRecord 一次请求的日志
+type logRecord struct {
+	opts     *logOptions
+	req      *http.Request
+	start    time.Time
+	reqBody  *captureBody
+	respBody *captureBody
+}
+
+func (r *logRecord) log(resp *http.Response, err error) {
+	fields, level, msg := r.entry(resp, err)
+	logger.Fields(fields).Log(level, msg)
+}
+
+// entry 构建日志字段, 返回字段、日志级别及消息
+func (r *logRecord) entry(resp *http.Response, err error) (map[string]any, string, string) {
+	o := r.opts
+	fields := map[string]any{
+		"method":    r.req.Method,
+		"url":       o.redactURL(r.req.URL),
+		"latency":   time.Since(r.start).String(),
+		"req_bytes": r.reqBody.size(),
+	}
+	if id := r.req.Header.Get(HeaderRequestID); id != "" {
+		fields["request_id"] = id
+	}
+	if o.headers {
+		fields["req_headers"] = o.redactHeader(r.req.Header)
+	}
+	if o.bodyLimit > 0 && r.reqBody != nil {
+		if body := o.formatBody(r.req.Header.Get("Content-Type"), r.reqBody); body != "" {
+			fields["req_body"] = body
+		}
+	}
+
+	if err != nil {
+		fields["error"] = err.Error()
+		return fields, o.levels[OutcomeFailure], "[http.client] request failed"
+	}
+
+	fields["status"] = resp.StatusCode
+	fields["resp_bytes"] = r.respBody.size()
+	if o.headers {
+		fields["resp_headers"] = o.redactHeader(resp.Header)
+	}
+	if o.bodyLimit > 0 {
+		if body := o.formatBody(resp.Header.Get("Content-Type"), r.respBody); body != "" {
+			fields["resp_body"] = body
+		}
+	}
+
+	outcome := OutcomeSuccess
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		outcome = OutcomeServerError
+	case resp.StatusCode >= http.StatusBadRequest:
+		outcome = OutcomeClientError
+	}
+	return fields, o.levels[outcome], "[http.client] request"
+}
+
+// redactURL 脱敏 query 参数
+func (o *logOptions) redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	q := u.Query()
+	for k := range q {
+		if _, ok := o.redactFields[strings.ToLower(k)]; ok {
+			q.Set(k, redacted)
+		}
+	}
+	c := *u
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// redactHeader 脱敏请求头
+func (o *logOptions) redactHeader(h http.Header) map[string]string {
+	m := make(map[string]string, len(h))
+	for k, vs := range h {
+		if _, ok := o.redactHeaders[http.CanonicalHeaderKey(k)]; ok {
+			m[k] = redacted
+			continue
+		}
+		m[k] = strings.Join(vs, ", ")
+	}
+	return m
+}
+
+// formatBody 格式化消息体, json 及表单字段脱敏, 无法解析的 json 不记录以免泄露敏感字段,
+// 不依赖 Content-Type 判断 json, 以免错误的响应头导致泄露
+func (o *logOptions) formatBody(contentType string, body *captureBody) string {
+	data, n := body.snapshot()
+	if len(data) == 0 {
+		return ""
+	}
+	truncated := n > int64(len(data))
+	trimmed := bytes.TrimSpace(data)
+
+	switch {
+	case strings.Contains(contentType, "json") || (len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')):
+		var v any
+		if truncated || json.Unmarshal(data, &v) != nil {
+			return fmt.Sprintf("[%d bytes json omitted]", n)
+		}
+		b, _ := json.Marshal(o.redactJSON(v))
+		return string(b)
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		q, err := url.ParseQuery(string(data))
+		if truncated || err != nil {
+			return fmt.Sprintf("[%d bytes form omitted]", n)
+		}
+		for k := range q {
+			if _, ok := o.redactFields[strings.ToLower(k)]; ok {
+				q.Set(k, redacted)
+			}
+		}
+		return q.Encode()
+	case !utf8.Valid(data) && !truncated:
+		return fmt.Sprintf("[%d bytes binary]", n)
+	}
+	if truncated {
+		return string(data) + fmt.Sprintf("...(%d bytes truncated)", n-int64(len(data)))
+	}
+	return string(data)
+}
+
+// redactJSON 递归脱敏 json 字段
+func (o *logOptions) redactJSON(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			if _, ok := o.redactFields[strings.ToLower(k)]; ok {
+				val[k] = redacted
+				continue
+			}
+			val[k] = o.redactJSON(item)
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = o.redactJSON(item)
+		}
+	}
+	return v
+}
+
+// captureBody 统计字节数并保留前 limit 字节
+// 请求体由 transport 的写入协程读取, 可能与记录日志并发, 因此需要加锁
+type captureBody struct {
+	io.ReadCloser
+	mu      sync.Mutex
+	n       int64
+	limit   int
+	buf     []byte
+	once    sync.Once
+	onClose func()
+}
+
+func (c *captureBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.mu.Lock()
+	c.n += int64(n)
+	if rest := c.limit - len(c.buf); rest > 0 && n > 0 {
+		c.buf = append(c.buf, p[:min(n, rest)]...)
+	}
+	c.mu.Unlock()
+	return n, err
+}
+
+func (c *captureBody) Close() error {
+	err := c.ReadCloser.Close()
+	if c.onClose != nil {
+		c.once.Do(c.onClose)
+	}
+	return err
+}
+
+func (c *captureBody) size() int64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+// snapshot 复制已保留的内容及已读取的字节数
+func (c *captureBody) snapshot() ([]byte, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]byte(nil), c.buf...), c.n
+}
+
+type debugKey struct{}
+
+// withDebug 标记请求需要记录调试日志
+func withDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+// debugTransport WithDebug 的请求记录包含请求头及消息体的脱敏日志
+type debugTransport struct {
+	next http.RoundTripper
+	doer Doer
+}
+
+func newDebugTransport(next http.RoundTripper) *debugTransport {
+	log := LoggingInterceptor(WithLogHeaders(), WithLogBody(debugBodyLimit))
+	return &debugTransport{next: next, doer: log(DoerFunc(next.RoundTrip))}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if debug, _ := req.Context().Value(debugKey{}).(bool); debug {
+		return t.doer.Do(req)
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/binbinly/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// readCapture 读取并关闭消息体
+func readCapture(t *testing.T, body string, limit int) *captureBody {
+	c := &captureBody{ReadCloser: io.NopCloser(strings.NewReader(body)), limit: limit}
+	_, err := io.ReadAll(c)
+	assert.NoError(t, err)
+	return c
+}
+
+func TestLoggingEntry(t *testing.T) {
+	o := newLogOptions(WithLogHeaders(), WithLogBody(64), WithRedactFields("card"), WithRedactHeaders("X-Secret"),
+		WithLogLevel(OutcomeClientError, logger.ErrorLevel))
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/login?token=abc&page=1", nil)
+	req.Header.Set("Authorization", "Bearer xxx")
+	req.Header.Set("X-Secret", "s")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderRequestID, "req-1")
+	rec := &logRecord{
+		opts:     o,
+		req:      req,
+		reqBody:  readCapture(t, `{"user":"foo","Password":"p","cards":[{"card":"1234"}]}`, 64),
+		respBody: readCapture(t, "user=foo&access_token=t", 64),
+	}
+	resp := &http.Response{StatusCode: http.StatusUnauthorized, Header: http.Header{
+		"Content-Type": {"application/x-www-form-urlencoded"},
+		"Set-Cookie":   {"sid=1"},
+	}}
+
+	fields, level, _ := rec.entry(resp, nil)
+	assert.Equal(t, logger.ErrorLevel, level)
+	assert.Equal(t, "http://example.com/login?page=1&token=%2A%2A%2A", fields["url"])
+	assert.Equal(t, "req-1", fields["request_id"])
+	assert.Equal(t, http.StatusUnauthorized, fields["status"])
+	assert.Equal(t, int64(55), fields["req_bytes"])
+	assert.Equal(t, int64(23), fields["resp_bytes"])
+	assert.Equal(t, `{"Password":"***","cards":[{"card":"***"}],"user":"foo"}`, fields["req_body"])
+	assert.Equal(t, "access_token=%2A%2A%2A&user=foo", fields["resp_body"])
+	reqHeaders := fields["req_headers"].(map[string]string)
+	assert.Equal(t, "***", reqHeaders["Authorization"])
+	assert.Equal(t, "***", reqHeaders["X-Secret"])
+	assert.Equal(t, "***", fields["resp_headers"].(map[string]string)["Set-Cookie"])
+
+	// 截断的 json 不记录, 文本截断
+	rec.reqBody = readCapture(t, `{"password":"`+strings.Repeat("x", 100)+`"}`, 64)
+	rec.respBody = readCapture(t, strings.Repeat("a", 70), 64)
+	resp.Header.Set("Content-Type", "text/plain")
+	resp.StatusCode = http.StatusOK
+	fields, level, _ = rec.entry(resp, nil)
+	assert.Equal(t, logger.InfoLevel, level)
+	assert.Equal(t, "[115 bytes json omitted]", fields["req_body"])
+	assert.Equal(t, strings.Repeat("a", 64)+"...(6 bytes truncated)", fields["resp_body"])
+
+	fields, level, msg := rec.entry(nil, errors.New("boom"))
+	assert.Equal(t, logger.ErrorLevel, level)
+	assert.Equal(t, "boom", fields["error"])
+	assert.Equal(t, "[http.client] request failed", msg)
+
+	assert.Equal(t, "[3 bytes binary]", o.formatBody("", readCapture(t, "\xff\xfe\xfd", 64)))
+	assert.Equal(t, "", o.formatBody("", readCapture(t, "", 64)))
+}
+
+func TestLoggingInterceptor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(b)
+	}))
+	defer srv.Close()
+
+	for _, client := range []Client{
+		NewRawClientWithOptions(WithInterceptors(LoggingInterceptor(WithLogBody(16)))),
+		NewRestyClient(WithInterceptors(LoggingInterceptor(WithLogBody(16)))),
+		NewRawClient(), NewRestyClient(),
+	} {
+		body := `{"password":"secret","name":"foo"}`
+		b, err := client.PostJSON(context.Background(), srv.URL, []byte(body), WithDebug())
+		assert.NoError(t, err)
+		assert.Equal(t, body, string(b))
+	}
+}
+
+func TestLoggingJSONWithoutContentType(t *testing.T) {
+	o := newLogOptions(WithLogBody(64))
+	assert.Equal(t, `{"password":"***"}`, o.formatBody("text/plain", readCapture(t, ` {"password":"p"}`, 64)))
+	assert.Equal(t, "[24 bytes json omitted]", o.formatBody("text/plain", readCapture(t, `{"password":"secret..."}`, 10)))
+}
+
+func TestCaptureBodyConcurrent(t *testing.T) {
+	// 请求体由 transport 的写入协程读取时, 同时记录日志
+	o := newLogOptions(WithLogBody(64))
+	c := &captureBody{ReadCloser: io.NopCloser(strings.NewReader(strings.Repeat("a", 1<<16))), limit: 64}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p := make([]byte, 8)
+		for {
+			if _, err := c.Read(p); err != nil {
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		_ = o.formatBody("text/plain", c)
+		_ = c.size()
+	}
+	<-done
+	assert.Equal(t, int64(1<<16), c.size())
+}
@@ -4,13 +4,10 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
-	"fmt"
+	"errors"
 	"io"
-	"mime/multipart"
-	"net"
 	"net/http"
 	"net/url"
-	"strings"
 	"time"
 )
 
@@ -23,29 +20,23 @@ type rawClient struct {
 	timeout time.Duration
 }
 
-// NewRawClient 实例化 http client
+// NewRawClient 实例化 http client, tlsCfg 不为空时使用第一个 TLS 配置
+// 需要连接池, 代理, 重试等配置时使用 NewRawClientWithOptions
 func NewRawClient(tlsCfg ...*tls.Config) Client {
-	t := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 60 * time.Second,
-		}).DialContext,
-		MaxIdleConns:          0,
-		MaxIdleConnsPerHost:   1000,
-		MaxConnsPerHost:       1000,
-		IdleConnTimeout:       60 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
+	var opts []Option
+	if len(tlsCfg) != 0 && tlsCfg[0] != nil {
+		opts = append(opts, WithTLSConfig(tlsCfg[0]))
 	}
+	return NewRawClientWithOptions(opts...)
+}
 
-	if len(tlsCfg) != 0 {
-		t.TLSClientConfig = tlsCfg[0]
-	}
+// NewRawClientWithOptions 使用选项实例化 http client, 代理或证书配置有误时 panic
+func NewRawClientWithOptions(opts ...Option) Client {
+	o := newOptions(opts...)
 
 	return &rawClient{
 		client: &http.Client{
-			Transport: t,
+			Transport: o.transport(o.baseTransport()),
 		},
 		timeout: defaultTimeout,
 	}
@@ -53,86 +44,98 @@ func NewRawClient(tlsCfg ...*tls.Config) Client {
 
 // Get http get request
 func (r *rawClient) Get(ctx context.Context, url string, options ...ClientOption) ([]byte, error) {
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return r.do(ctx, req, options...)
+	return responseBody(r.Do(ctx, http.MethodGet, url, nil, options...))
 }
 
 // Post http post request
 func (r *rawClient) Post(ctx context.Context, url string, data map[string]string, options ...ClientOption) ([]byte, error) {
 	options = append(options, WithHTTPHeader("Content-Type", "application/x-www-form-urlencoded; charset=utf-8"))
 	formData := r.buildForms(data).Encode()
-	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(formData))
-	if err != nil {
-		return nil, err
-	}
 
-	return r.do(ctx, req, options...)
+	return responseBody(r.Do(ctx, http.MethodPost, url, []byte(formData), options...))
 }
 
 // PostJSON http json post request
 func (r *rawClient) PostJSON(ctx context.Context, url string, body []byte, options ...ClientOption) ([]byte, error) {
 	options = append(options, WithHTTPHeader("Content-Type", "application/json; charset=utf-8"))
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	return responseBody(r.Do(ctx, http.MethodPost, url, body, options...))
+}
 
-	if err != nil {
-		return nil, err
-	}
+// Put http put request
+func (r *rawClient) Put(ctx context.Context, url string, body []byte, options ...ClientOption) ([]byte, error) {
+	return responseBody(r.Do(ctx, http.MethodPut, url, body, options...))
+}
 
-	return r.do(ctx, req, options...)
+// Patch http patch request
+func (r *rawClient) Patch(ctx context.Context, url string, body []byte, options ...ClientOption) ([]byte, error) {
+	return responseBody(r.Do(ctx, http.MethodPatch, url, body, options...))
 }
 
-// Upload 文件上传
-func (r *rawClient) Upload(ctx context.Context, url string, form UploadForm, options ...ClientOption) ([]byte, error) {
-	media, err := form.Buffer()
+// Delete http delete request
+func (r *rawClient) Delete(ctx context.Context, url string, options ...ClientOption) ([]byte, error) {
+	return responseBody(r.Do(ctx, http.MethodDelete, url, nil, options...))
+}
+
+// Head http head request
+func (r *rawClient) Head(ctx context.Context, url string, options ...ClientOption) (*Response, error) {
+	return r.Do(ctx, http.MethodHead, url, nil, options...)
+}
 
+// PostData http post request, encodes data and decodes reply by content type
+func (r *rawClient) PostData(ctx context.Context, url string, data, reply any, options ...ClientOption) error {
+	body, c, options, err := encodeRequest(data, options)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	buf := bytes.NewBuffer(make([]byte, 0, 4<<10)) // 4kb
-	w := multipart.NewWriter(buf)
+	resp, err := r.Do(ctx, http.MethodPost, url, body, options...)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(resp.Header, resp.Body, c, reply)
+}
+
+// Upload 文件上传, multipart 请求体通过 io.Pipe 流式发送
+func (r *rawClient) Upload(ctx context.Context, url string, form UploadForm, options ...ClientOption) ([]byte, error) {
+	return upload(ctx, r, url, form, options)
+}
 
-	fw, err := w.CreateFormFile(form.FieldName(), form.FileName())
+// Download 文件下载, 支持断点续传及 md5 校验
+func (r *rawClient) Download(ctx context.Context, url string, w io.Writer, options ...ClientOption) (int64, error) {
+	return download(ctx, r, url, w, options)
+}
 
+// Do http request
+func (r *rawClient) Do(ctx context.Context, method, url string, body []byte, options ...ClientOption) (*Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
 	if err != nil {
 		return nil, err
 	}
-
-	if _, err = fw.Write(media); err != nil {
+	resp, err := r.send(ctx, req, false, options...)
+	if err != nil {
 		return nil, err
 	}
 
-	// add extra fields
-	if extraFields := form.ExtraFields(); len(extraFields) != 0 {
-		for k, v := range extraFields {
-			if err = w.WriteField(k, v); err != nil {
-				return nil, err
-			}
-		}
-	}
-
-	options = append(options, WithHTTPHeader("Content-Type", w.FormDataContentType()))
-
-	// Don't forget to close the multipart writer.
-	// If you don't close it, your request will be missing the terminating boundary.
-	w.Close()
-
-	req, err := http.NewRequest(http.MethodPost, url, buf)
+	return readResponse(resp)
+}
 
+// stream 发送请求, 返回未读取的响应, 超时为读写请求体及响应体的空闲超时
+func (r *rawClient) stream(ctx context.Context, method, url string, body io.Reader, options ...ClientOption) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, body)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.do(ctx, req, options...)
+	return r.send(ctx, req, true, options...)
 }
 
-func (r *rawClient) do(ctx context.Context, req *http.Request, options ...ClientOption) ([]byte, error) {
+// send 发送请求, 关闭响应体时释放超时 context
+func (r *rawClient) send(ctx context.Context, req *http.Request, stream bool, options ...ClientOption) (*http.Response, error) {
 	settings := &httpSettings{timeout: r.timeout}
 
 	if len(options) != 0 {
@@ -150,6 +153,17 @@ func (r *rawClient) do(ctx context.Context, req *http.Request, options ...Client
 		}
 	}
 
+	// query
+	if len(settings.query) != 0 {
+		q := req.URL.Query()
+		for k, vs := range settings.query {
+			for _, v := range vs {
+				q.Add(k, v)
+			}
+		}
+		req.URL.RawQuery = q.Encode()
+	}
+
 	// cookies
 	if len(settings.cookies) != 0 {
 		for _, v := range settings.cookies {
@@ -162,36 +176,103 @@ func (r *rawClient) do(ctx context.Context, req *http.Request, options ...Client
 	}
 
 	// timeout
-	ctx, cancel := context.WithTimeout(ctx, settings.timeout)
-	defer cancel()
+	ctx, idle, cancel := withTimeout(ctx, settings.timeout, stream)
+	ctx = withRetryPolicy(ctx, settings.retry)
+	if settings.debug {
+		ctx = withDebug(ctx)
+	}
 
+	if req.Body != nil {
+		req.Body = idle.readCloser(req.Body)
+	}
 	resp, err := r.client.Do(req.WithContext(ctx))
 
 	if err != nil {
 		// If the context has been canceled, the context's error is probably more useful.
 		select {
 		case <-ctx.Done():
-			err = ctx.Err()
+			err = context.Cause(ctx)
 		default:
 		}
+		cancel()
 
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	resp.Body = &cancelBody{ReadCloser: idle.readCloser(resp.Body), cancel: cancel}
+
+	return resp, nil
+}
+
+// cancelBody 关闭响应体时取消请求 context
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// withTimeout 普通请求的超时覆盖整个请求, 流式请求的超时为空闲超时,
+// 请求体或响应体在 timeout 内没有读写时取消请求, 避免大文件传输被总超时中断
+func withTimeout(ctx context.Context, timeout time.Duration, stream bool) (context.Context, *idleTimer, context.CancelFunc) {
+	if !stream {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		return ctx, nil, cancel
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	t := &idleTimer{ctx: ctx, timeout: timeout}
+	t.timer = time.AfterFunc(timeout, func() { cancel(context.DeadlineExceeded) })
+	return ctx, t, func() {
+		t.timer.Stop()
+		cancel(context.Canceled)
+	}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		io.Copy(io.Discard, resp.Body)
+// idleTimer 流式请求的空闲计时器, nil 时不做处理
+type idleTimer struct {
+	ctx     context.Context
+	timeout time.Duration
+	timer   *time.Timer
+}
 
-		return nil, fmt.Errorf("error http code: %d", resp.StatusCode)
+// reader 读取到数据时重置计时器
+func (t *idleTimer) reader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
 	}
+	return &idleReader{Reader: r, t: t}
+}
 
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+// readCloser 读取到数据时重置计时器
+func (t *idleTimer) readCloser(rc io.ReadCloser) io.ReadCloser {
+	if t == nil {
+		return rc
 	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{t.reader(rc), rc}
+}
 
-	return b, nil
+type idleReader struct {
+	io.Reader
+	t *idleTimer
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.t.timer.Reset(r.t.timeout)
+	}
+	if err != nil && err != io.EOF && errors.Is(context.Cause(r.t.ctx), context.DeadlineExceeded) {
+		err = context.DeadlineExceeded
+	}
+	return n, err
 }
 
 // buildForms build post Form data
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/binbinly/pkg/codec"
+)
+
+// maxErrorBody HTTPError.Error() 中最多展示的响应体长度
+const maxErrorBody = 512
+
+// Response http 响应
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Decode 根据响应的 Content-Type 解码响应体, 未设置时使用json
+func (r *Response) Decode(v any) error {
+	return decodeResponse(r.Header, r.Body, codec.JSONEncoding{}, v)
+}
+
+// HTTPError 响应状态码不是 2xx 时返回的错误, 包含状态码及响应体
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Error 格式化
+func (e *HTTPError) Error() string {
+	body := e.Body
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	return fmt.Sprintf("error http code: %d, %s %s, body: %s", e.StatusCode, e.Method, e.URL, body)
+}
+
+// newResponse 构建响应, 状态码不是 2xx 时同时返回 *HTTPError
+func newResponse(method, reqURL string, code int, header http.Header, body []byte) (*Response, error) {
+	resp := &Response{StatusCode: code, Header: header, Body: body}
+	if code < http.StatusOK || code >= http.StatusMultipleChoices {
+		return resp, &HTTPError{Method: method, URL: reqURL, StatusCode: code, Header: header, Body: body}
+	}
+	return resp, nil
+}
+
+// responseBody 获取响应体
+func responseBody(resp *Response, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// WithQuery specifies the query parameter to http request.
+func WithQuery(key, value string) ClientOption {
+	return func(s *httpSettings) {
+		if s.query == nil {
+			s.query = url.Values{}
+		}
+		s.query.Add(key, value)
+	}
+}
+
+// WithQueryParams specifies the query parameters to http request.
+func WithQueryParams(params url.Values) ClientOption {
+	return func(s *httpSettings) {
+		if s.query == nil {
+			s.query = url.Values{}
+		}
+		for k, vs := range params {
+			for _, v := range vs {
+				s.query.Add(k, v)
+			}
+		}
+	}
+}
+
+// BuildURL 在 reqURL 上追加 query 参数
+func BuildURL(reqURL string, query url.Values) (string, error) {
+	if len(query) == 0 {
+		return reqURL, nil
+	}
+	u, err := url.Parse(reqURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for k, vs := range query {
+		for _, v := range vs {
+			q.Add(k, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// DoJSON 发送请求, body 不为空时按 WithContentType 对应的编码(默认json)序列化,
+// 响应按 Content-Type 解码为 T
+func DoJSON[T any](ctx context.Context, c Client, method, reqURL string, body any, options ...ClientOption) (T, error) {
+	var out T
+	var data []byte
+	rc := codec.Codec(codec.JSONEncoding{})
+	if body != nil {
+		var err error
+		if data, rc, options, err = encodeRequest(body, options); err != nil {
+			return out, err
+		}
+	}
+
+	resp, err := c.Do(ctx, method, reqURL, data, options...)
+	if err != nil {
+		return out, err
+	}
+	err = decodeResponse(resp.Header, resp.Body, rc, &out)
+	return out, err
+}
@@ -2,6 +2,8 @@ package http
 
 import (
 	"context"
+	"io"
+	"net/http"
 	"time"
 
 	"github.com/go-resty/resty/v2"
@@ -10,60 +12,134 @@ import (
 // docs: https://github.com/go-resty/resty
 
 type restyClient struct {
+	client  *resty.Client
 	timeout time.Duration
 }
 
-// NewRestyClient 创建http client客户端
-func NewRestyClient() Client {
+// NewRestyClient 创建http client客户端, 所有请求共享同一个 transport 及连接池, 代理或证书配置有误时 panic
+func NewRestyClient(opts ...Option) Client {
+	o := newOptions(opts...)
+	client := resty.New()
+	// 与 raw client 保持一致, 不在请求间共享 cookie
+	client.SetCookieJar(nil)
+	client.SetTransport(o.transport(o.baseTransport()))
+
 	return &restyClient{
+		client:  client,
 		timeout: defaultTimeout,
 	}
 }
 
 // Get 发送get请求
 func (r *restyClient) Get(ctx context.Context, url string, options ...ClientOption) ([]byte, error) {
-	client := resty.New()
-	r.setting(ctx, client, options...)
-
-	resp, err := client.R().Get(url)
-	if err != nil {
-		return nil, err
-	}
-	return resp.Body(), nil
+	return responseBody(r.Do(ctx, http.MethodGet, url, nil, options...))
 }
 
 // Post 发送form post请求
 func (r *restyClient) Post(ctx context.Context, url string, data map[string]string, options ...ClientOption) ([]byte, error) {
 	options = append(options, WithHTTPHeader("Content-Type", "application/x-www-form-urlencoded; charset=utf-8"))
-	client := resty.New()
-	r.setting(ctx, client, options...)
 
-	resp, err := client.R().SetFormData(data).Post(url)
-	if err != nil {
-		return nil, err
-	}
-	return resp.Body(), nil
+	req, _, cancel := r.request(ctx, false, options...)
+	defer cancel()
+
+	return responseBody(r.execute(req.SetFormData(data), http.MethodPost, url))
 }
 
 // PostJSON 发送post raw json 请求
 func (r *restyClient) PostJSON(ctx context.Context, url string, body []byte, options ...ClientOption) ([]byte, error) {
 	options = append(options, WithHTTPHeader("Content-Type", "application/json; charset=utf-8"))
-	client := resty.New()
-	r.setting(ctx, client, options...)
 
-	resp, err := client.R().SetBody(body).Post(url)
+	return responseBody(r.Do(ctx, http.MethodPost, url, body, options...))
+}
+
+// Put 发送put请求
+func (r *restyClient) Put(ctx context.Context, url string, body []byte, options ...ClientOption) ([]byte, error) {
+	return responseBody(r.Do(ctx, http.MethodPut, url, body, options...))
+}
+
+// Patch 发送patch请求
+func (r *restyClient) Patch(ctx context.Context, url string, body []byte, options ...ClientOption) ([]byte, error) {
+	return responseBody(r.Do(ctx, http.MethodPatch, url, body, options...))
+}
+
+// Delete 发送delete请求
+func (r *restyClient) Delete(ctx context.Context, url string, options ...ClientOption) ([]byte, error) {
+	return responseBody(r.Do(ctx, http.MethodDelete, url, nil, options...))
+}
+
+// Head 发送head请求
+func (r *restyClient) Head(ctx context.Context, url string, options ...ClientOption) (*Response, error) {
+	return r.Do(ctx, http.MethodHead, url, nil, options...)
+}
+
+// PostData 发送post请求, 按 content type 编码请求体并解码响应
+func (r *restyClient) PostData(ctx context.Context, url string, data, reply any, options ...ClientOption) error {
+	body, c, options, err := encodeRequest(data, options)
 	if err != nil {
+		return err
+	}
+	resp, err := r.Do(ctx, http.MethodPost, url, body, options...)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(resp.Header, resp.Body, c, reply)
+}
+
+// Upload 上传, multipart 请求体通过 io.Pipe 流式发送
+func (r *restyClient) Upload(ctx context.Context, url string, form UploadForm, options ...ClientOption) ([]byte, error) {
+	return upload(ctx, r, url, form, options)
+}
+
+// Download 下载, 支持断点续传及 md5 校验
+func (r *restyClient) Download(ctx context.Context, url string, w io.Writer, options ...ClientOption) (int64, error) {
+	return download(ctx, r, url, w, options)
+}
+
+// Do 发送请求
+func (r *restyClient) Do(ctx context.Context, method, url string, body []byte, options ...ClientOption) (*Response, error) {
+	req, _, cancel := r.request(ctx, false, options...)
+	defer cancel()
+
+	if body != nil {
+		req.SetBody(body)
+	}
+	return r.execute(req, method, url)
+}
+
+// stream 发送请求, 不解析响应, io.Reader 请求体不会被缓存, 关闭响应体时释放超时 context,
+// 超时为读写请求体及响应体的空闲超时
+func (r *restyClient) stream(ctx context.Context, method, url string, body io.Reader, options ...ClientOption) (*http.Response, error) {
+	req, idle, cancel := r.request(ctx, true, options...)
+	req.SetDoNotParseResponse(true)
+	if body != nil {
+		req.SetBody(idle.reader(body))
+	}
+	resp, err := req.Execute(method, url)
+	if err != nil {
+		select {
+		case <-req.Context().Done():
+			err = context.Cause(req.Context())
+		default:
+		}
+		cancel()
 		return nil, err
 	}
-	return resp.Body(), nil
+	raw := resp.RawResponse
+	raw.Body = &cancelBody{ReadCloser: idle.readCloser(raw.Body), cancel: cancel}
+	return raw, nil
 }
 
-// Upload 上传
-func (r *restyClient) Upload(ctx context.Context, url string, form UploadForm, options ...ClientOption) (b []byte, err error) {
-	return
+// execute 执行请求并构建响应
+func (r *restyClient) execute(req *resty.Request, method, url string) (*Response, error) {
+	resp, err := req.Execute(method, url)
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(method, resp.Request.URL, resp.StatusCode(), resp.Header(), resp.Body())
 }
 
-func (r *restyClient) setting(ctx context.Context, client *resty.Client, options ...ClientOption) {
+// request 创建请求并应用请求选项, stream 时超时为空闲超时, 请求结束后需调用 cancel
+func (r *restyClient) request(ctx context.Context, stream bool, options ...ClientOption) (*resty.Request, *idleTimer, context.CancelFunc) {
 	settings := &httpSettings{timeout: r.timeout}
 
 	if len(options) != 0 {
@@ -74,25 +150,31 @@ func (r *restyClient) setting(ctx context.Context, client *resty.Client, options
 		}
 	}
 
-	if r.timeout != 0 {
-		client.SetTimeout(settings.timeout)
+	// timeout
+	ctx, idle, cancel := withTimeout(ctx, settings.timeout, stream)
+	ctx = withRetryPolicy(ctx, settings.retry)
+	if settings.debug {
+		ctx = withDebug(ctx)
 	}
+	req := r.client.R().SetContext(ctx)
 
 	// headers
 	if len(settings.headers) != 0 {
-		client.SetHeaders(settings.headers)
+		req.SetHeaders(settings.headers)
 	}
 
 	// cookies
 	if len(settings.cookies) != 0 {
-		client.SetCookies(settings.cookies)
+		req.SetCookies(settings.cookies)
 	}
 
-	if settings.debug {
-		client.SetDebug(true)
+	if settings.close {
+		req.SetHeader("Connection", "close")
 	}
 
-	if settings.close {
-		client.SetCloseConnection(true)
+	if len(settings.query) != 0 {
+		req.SetQueryParamsFromValues(settings.query)
 	}
+
+	return req, idle, cancel
 }
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy 重试策略
+type RetryPolicy struct {
+	// MaxAttempts 最大请求次数, 包含首次请求
+	MaxAttempts int
+	// BaseDelay 退避基础时间, 第n次重试的最大等待时间为 BaseDelay * 2^n
+	BaseDelay time.Duration
+	// MaxDelay 退避最大等待时间, Retry-After 超过该时间时不再重试, 直接返回响应
+	MaxDelay time.Duration
+	// RetryStatus 可重试的响应状态码
+	RetryStatus []int
+	// RetryMethods 可重试的请求方法, 默认只重试幂等方法
+	// 带有 Idempotency-Key 头的请求也会被重试
+	RetryMethods []string
+	// RetryIf 自定义是否重试, 为空时使用 RetryStatus 及 IsRetryableError 判断
+	RetryIf func(resp *http.Response, err error) bool
+}
+
+// DefaultRetryPolicy 默认重试策略
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		RetryStatus: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryMethods: []string{
+			http.MethodGet,
+			http.MethodHead,
+			http.MethodOptions,
+			http.MethodPut,
+			http.MethodDelete,
+		},
+	}
+}
+
+// WithRetry specifies the retry policy to http request.
+func WithRetry(p *RetryPolicy) ClientOption {
+	return func(s *httpSettings) {
+		s.retry = p
+	}
+}
+
+// IsRetryableError 判断错误是否可重试, 如连接被重置、连接被拒绝、超时等临时性错误
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryable 判断请求是否允许重试
+func (p *RetryPolicy) retryable(req *http.Request) bool {
+	// 请求体无法重放时不重试
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if req.Header.Get("Idempotency-Key") != "" {
+		return true
+	}
+	for _, m := range p.RetryMethods {
+		if m == req.Method {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldRetry 判断响应或错误是否需要重试
+func (p *RetryPolicy) shouldRetry(resp *http.Response, err error) bool {
+	if p.RetryIf != nil {
+		return p.RetryIf(resp, err)
+	}
+	if err != nil {
+		return IsRetryableError(err)
+	}
+	for _, code := range p.RetryStatus {
+		if code == resp.StatusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff 指数退避加全随机抖动(full jitter), 响应带有 Retry-After 时优先使用,
+// Retry-After 超过 MaxDelay 时返回 false, 提前重试只会再次被拒绝
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return d, p.MaxDelay <= 0 || d <= p.MaxDelay
+		}
+	}
+	if p.BaseDelay <= 0 {
+		return 0, true
+	}
+	d := p.BaseDelay << uint(attempt)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1)), true
+}
+
+// parseRetryAfter 解析 Retry-After, 支持秒数及 HTTP 日期格式
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(v); err == nil {
+		if sec < 0 {
+			return 0, false
+		}
+		return time.Duration(sec) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+type retryKey struct{}
+
+// withRetryPolicy 将重试策略放入 context, 由 retryTransport 读取
+func withRetryPolicy(ctx context.Context, p *RetryPolicy) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, retryKey{}, p)
+}
+
+// retryTransport 按请求 context 中的重试策略重试
+type retryTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p, ok := req.Context().Value(retryKey{}).(*RetryPolicy)
+	if !ok || p.MaxAttempts <= 1 || !p.retryable(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 {
+			r = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				r.Body = body
+			}
+		}
+
+		resp, err := t.next.RoundTrip(r)
+		if attempt+1 >= p.MaxAttempts || !p.shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay, ok := p.backoff(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			// 丢弃响应体以复用连接
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
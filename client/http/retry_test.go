@@ -0,0 +1,140 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRetryPolicy() *RetryPolicy {
+	p := DefaultRetryPolicy()
+	p.BaseDelay = time.Millisecond
+	p.MaxDelay = 10 * time.Millisecond
+	return p
+}
+
+// flakyServer 前 failures 次请求返回 status
+func flakyServer(failures int32, status int, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(calls, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write(append([]byte("ok"), body...))
+	}))
+}
+
+func TestRetry(t *testing.T) {
+	for _, client := range []Client{NewRawClient(), NewRestyClient()} {
+		t.Run("retry get", func(t *testing.T) {
+			var calls int32
+			srv := flakyServer(2, http.StatusServiceUnavailable, &calls)
+			defer srv.Close()
+
+			b, err := client.Get(context.Background(), srv.URL, WithRetry(testRetryPolicy()))
+			assert.Nil(t, err)
+			assert.Equal(t, "ok", string(b))
+			assert.Equal(t, int32(3), calls)
+		})
+
+		t.Run("post not retried", func(t *testing.T) {
+			var calls int32
+			srv := flakyServer(1, http.StatusBadGateway, &calls)
+			defer srv.Close()
+
+			_, _ = client.PostJSON(context.Background(), srv.URL, []byte("{}"), WithRetry(testRetryPolicy()))
+			assert.Equal(t, int32(1), calls)
+		})
+
+		t.Run("replay body", func(t *testing.T) {
+			var calls int32
+			srv := flakyServer(1, http.StatusBadGateway, &calls)
+			defer srv.Close()
+
+			b, err := client.PostJSON(context.Background(), srv.URL, []byte(`{"a":1}`),
+				WithRetry(testRetryPolicy()), WithHTTPHeader("Idempotency-Key", "key-1"))
+			assert.Nil(t, err)
+			assert.Equal(t, `ok{"a":1}`, string(b))
+			assert.Equal(t, int32(2), calls)
+		})
+
+		t.Run("max attempts", func(t *testing.T) {
+			var calls int32
+			srv := flakyServer(10, http.StatusBadGateway, &calls)
+			defer srv.Close()
+
+			_, _ = client.Get(context.Background(), srv.URL, WithRetry(testRetryPolicy()))
+			assert.Equal(t, int32(3), calls)
+		})
+
+		t.Run("not retryable status", func(t *testing.T) {
+			var calls int32
+			srv := flakyServer(1, http.StatusBadRequest, &calls)
+			defer srv.Close()
+
+			_, _ = client.Get(context.Background(), srv.URL, WithRetry(testRetryPolicy()))
+			assert.Equal(t, int32(1), calls)
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	p := testRetryPolicy()
+	p.MaxDelay = 2 * time.Second
+	start := time.Now()
+	b, err := NewRawClient().Get(context.Background(), srv.URL, WithRetry(p))
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", string(b))
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+
+	// Retry-After 超过 MaxDelay 时不重试
+	atomic.StoreInt32(&calls, 0)
+	start = time.Now()
+	_, err = NewRawClient().Get(context.Background(), srv.URL, WithRetry(testRetryPolicy()))
+	var httpErr *HTTPError
+	if assert.True(t, errors.As(err, &httpErr)) {
+		assert.Equal(t, http.StatusTooManyRequests, httpErr.StatusCode)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Less(t, time.Since(start), time.Second)
+
+	d, ok := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.Greater(t, d, 58*time.Second)
+}
+
+func TestRetryContextCanceled(t *testing.T) {
+	var calls int32
+	srv := flakyServer(10, http.StatusServiceUnavailable, &calls)
+	defer srv.Close()
+
+	p := testRetryPolicy()
+	p.MaxAttempts = 100
+	p.BaseDelay = 50 * time.Millisecond
+	p.MaxDelay = 50 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+
+	_, err := NewRawClient().Get(ctx, srv.URL, WithRetry(p))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, calls, int32(100))
+}
@@ -1,8 +1,10 @@
 package http
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -25,11 +27,29 @@ type UploadForm interface {
 	Buffer() ([]byte, error)
 }
 
+// MultiFileForm is the optional interface for http upload with multiple files,
+// the form only implements UploadForm is uploaded as a single file read from Buffer.
+type MultiFileForm interface {
+	UploadForm
+
+	// Files returns all files of the form, the media of FieldName is the first
+	Files() []*FormFile
+}
+
+// FormFile 表单中的上传文件
+type FormFile struct {
+	FieldName string
+	FileName  string
+	// Open 打开文件数据流, 大小未知时 size 返回 -1
+	Open func() (rc io.ReadCloser, size int64, err error)
+}
+
 type httpUpload struct {
 	fieldName   string
 	filename    string
 	resourceURL string
 	extraFields map[string]string
+	files       []*FormFile
 }
 
 func (u *httpUpload) FieldName() string {
@@ -45,29 +65,29 @@ func (u *httpUpload) ExtraFields() map[string]string {
 }
 
 func (u *httpUpload) Buffer() ([]byte, error) {
-	if len(u.resourceURL) != 0 {
-		resp, err := http.Get(u.resourceURL)
-
-		if err != nil {
-			return nil, err
-		}
-
-		defer resp.Body.Close()
+	rc, _, err := u.open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("error http code: %d", resp.StatusCode)
-		}
+	return io.ReadAll(rc)
+}
 
-		return io.ReadAll(resp.Body)
-	}
+func (u *httpUpload) Files() []*FormFile {
+	files := make([]*FormFile, 0, len(u.files)+1)
+	files = append(files, &FormFile{FieldName: u.fieldName, FileName: u.filename, Open: u.open})
 
-	path, err := filepath.Abs(u.filename)
+	return append(files, u.files...)
+}
 
-	if err != nil {
-		return nil, err
+// open 打开主文件数据流, 优先使用资源地址
+func (u *httpUpload) open() (io.ReadCloser, int64, error) {
+	if len(u.resourceURL) != 0 {
+		return openURL(u.resourceURL)
 	}
 
-	return os.ReadFile(path)
+	return openFile(u.filename)
 }
 
 // UploadOption configures how we set up the http upload from.
@@ -87,6 +107,35 @@ func WithExtraField(key, value string) UploadOption {
 	}
 }
 
+// WithFile specifies an extra local file to http upload from.
+func WithFile(fieldName, filename string) UploadOption {
+	return func(u *httpUpload) {
+		u.files = append(u.files, &FormFile{
+			FieldName: fieldName,
+			FileName:  filepath.Base(filename),
+			Open: func() (io.ReadCloser, int64, error) {
+				return openFile(filename)
+			},
+		})
+	}
+}
+
+// WithFileReader specifies an extra file read from r to http upload from, size is -1 if unknown.
+func WithFileReader(fieldName, filename string, r io.Reader, size int64) UploadOption {
+	return func(u *httpUpload) {
+		u.files = append(u.files, &FormFile{
+			FieldName: fieldName,
+			FileName:  filename,
+			Open: func() (io.ReadCloser, int64, error) {
+				if rc, ok := r.(io.ReadCloser); ok {
+					return rc, size, nil
+				}
+				return io.NopCloser(r), size, nil
+			},
+		})
+	}
+}
+
 // NewUploadForm returns new upload form
 func NewUploadForm(fieldName, filename string, options ...UploadOption) UploadForm {
 	form := &httpUpload{
@@ -104,3 +153,120 @@ func NewUploadForm(fieldName, filename string, options ...UploadOption) UploadFo
 
 	return form
 }
+
+// openFile 打开本地文件
+func openFile(filename string) (io.ReadCloser, int64, error) {
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+
+	return f, fi.Size(), nil
+}
+
+// openURL 打开远程资源
+func openURL(resourceURL string) (io.ReadCloser, int64, error) {
+	resp, err := http.Get(resourceURL)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("error http code: %d", resp.StatusCode)
+	}
+
+	return resp.Body, resp.ContentLength, nil
+}
+
+// multipartBody 通过 io.Pipe 流式生成 multipart 请求体, 文件不会整体读入内存
+// 文件在返回前全部打开, 以便尽早返回打开错误并统计总大小
+func multipartBody(form UploadForm, fn ProgressFunc) (io.ReadCloser, string, error) {
+	files := formFiles(form)
+	readers := make([]io.ReadCloser, 0, len(files))
+	closeAll := func() {
+		for _, rc := range readers {
+			rc.Close()
+		}
+	}
+
+	p := &progress{fn: fn}
+	for _, f := range files {
+		rc, size, err := f.Open()
+		if err != nil {
+			closeAll()
+			return nil, "", err
+		}
+		readers = append(readers, rc)
+		if size < 0 || p.total < 0 {
+			p.total = -1
+		} else {
+			p.total += size
+		}
+	}
+
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+
+	go func() {
+		defer closeAll()
+
+		pw.CloseWithError(writeMultipart(w, form, files, readers, p))
+	}()
+
+	return pr, w.FormDataContentType(), nil
+}
+
+// formFiles 表单中的上传文件, 未实现 MultiFileForm 时由 FieldName, FileName 及 Buffer 构成单个文件
+func formFiles(form UploadForm) []*FormFile {
+	if mf, ok := form.(MultiFileForm); ok {
+		return mf.Files()
+	}
+
+	return []*FormFile{{
+		FieldName: form.FieldName(),
+		FileName:  form.FileName(),
+		Open: func() (io.ReadCloser, int64, error) {
+			buf, err := form.Buffer()
+			if err != nil {
+				return nil, 0, err
+			}
+			return io.NopCloser(bytes.NewReader(buf)), int64(len(buf)), nil
+		},
+	}}
+}
+
+func writeMultipart(w *multipart.Writer, form UploadForm, files []*FormFile, readers []io.ReadCloser, p *progress) error {
+	for i, f := range files {
+		fw, err := w.CreateFormFile(f.FieldName, f.FileName)
+		if err != nil {
+			return err
+		}
+
+		if _, err = io.Copy(io.MultiWriter(fw, p), readers[i]); err != nil {
+			return err
+		}
+	}
+
+	// add extra fields
+	for k, v := range form.ExtraFields() {
+		if err := w.WriteField(k, v); err != nil {
+			return err
+		}
+	}
+
+	// Don't forget to close the multipart writer.
+	// If you don't close it, your request will be missing the terminating boundary.
+	return w.Close()
+}
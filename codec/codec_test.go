@@ -0,0 +1,431 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type testUser struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestRegisteredCodec(t *testing.T) {
+	names := []string{"json", "json+gzip", "json+zstd", "json+snappy",
+		"gob", "gob+zstd", "msgpack", "msgpack+gzip", "msgpack+snappy"}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			c := GetCodec(name)
+			if !assert.NotNil(t, c) {
+				return
+			}
+			assert.Equal(t, name, c.Name())
+
+			in := &testUser{Name: "test-name", Age: 18}
+			data, err := c.Marshal(in)
+			assert.Nil(t, err)
+
+			out := &testUser{}
+			assert.Nil(t, c.Unmarshal(data, out))
+			assert.Equal(t, in, out)
+		})
+	}
+}
+
+func TestProtobufEncoding(t *testing.T) {
+	for _, c := range []Codec{ProtobufEncoding{}, GetCodec("proto+zstd")} {
+		data, err := c.Marshal(wrapperspb.String("test-val"))
+		assert.Nil(t, err)
+
+		out := &wrapperspb.StringValue{}
+		assert.Nil(t, c.Unmarshal(data, out))
+		assert.Equal(t, "test-val", out.GetValue())
+	}
+
+	_, err := ProtobufEncoding{}.Marshal(&testUser{})
+	assert.ErrorIs(t, err, ErrNotProtoMessage)
+}
+
+func TestGzipLevel(t *testing.T) {
+	in := &testUser{Name: "test-name", Age: 18}
+	// 不同压缩级别编码结果可以互相解码
+	data, err := WithGzip(JSONEncoding{}, gzip.BestSpeed).Marshal(in)
+	assert.Nil(t, err)
+
+	out := &testUser{}
+	assert.Nil(t, JSONGzipEncoding{}.Unmarshal(data, out))
+	assert.Equal(t, in, out)
+}
+
+func TestEnvelopeEncoding(t *testing.T) {
+	in := &testUser{Name: "test-name", Age: 18}
+	legacy, err := JSONEncoding{}.Marshal(in)
+	assert.Nil(t, err)
+	legacyGzip, err := JSONGzipEncoding{}.Marshal(in)
+	assert.Nil(t, err)
+
+	e := NewEnvelopeEncoding(CodecIDMsgpack, WithEnvelopeCompress(CompressZstd), WithEnvelopeVersion(2))
+	data, err := e.Marshal(in)
+	assert.Nil(t, err)
+
+	h, ok := ParseHeader(data)
+	assert.True(t, ok)
+	assert.Equal(t, Header{CodecID: CodecIDMsgpack, Compression: CompressZstd, Version: 2}, h)
+
+	// 新旧格式混合读取
+	reader := NewEnvelopeEncoding(CodecIDJSON)
+	for _, buf := range [][]byte{data, legacy, legacyGzip} {
+		out := &testUser{}
+		assert.Nil(t, reader.Unmarshal(buf, out))
+		assert.Equal(t, in, out)
+	}
+
+	version, err := reader.UnmarshalVersion(data, &testUser{})
+	assert.Nil(t, err)
+	assert.Equal(t, uint8(2), version)
+
+	data[1] = 0xFF
+	assert.ErrorIs(t, reader.Unmarshal(data, &testUser{}), ErrUnknownCodecID)
+}
+
+func TestStreamCodec(t *testing.T) {
+	names := []string{"json", "json+gzip", "json+zstd", "json+snappy",
+		"gob", "gob+gzip", "msgpack", "msgpack+zstd"}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			c := GetCodec(name)
+			var buf bytes.Buffer
+			enc := NewEncoder(c, &buf)
+			users := []*testUser{{Name: "a", Age: 1}, {Name: "b", Age: 2}}
+			for _, u := range users {
+				assert.Nil(t, enc.Encode(u))
+			}
+			assert.Nil(t, enc.Close())
+
+			dec := NewDecoder(c, &buf)
+			for _, u := range users {
+				out := &testUser{}
+				assert.Nil(t, dec.Decode(out))
+				assert.Equal(t, u, out)
+			}
+			assert.Nil(t, dec.Close())
+
+			// 重复关闭不会重复释放资源
+			assert.Nil(t, enc.Close())
+			assert.Nil(t, dec.Close())
+			assert.ErrorIs(t, enc.Encode(users[0]), ErrStreamClosed)
+			assert.ErrorIs(t, dec.Decode(&testUser{}), ErrStreamClosed)
+		})
+	}
+
+	// 流式编码结果可以整块解码
+	var buf bytes.Buffer
+	enc := NewEncoder(JSONGzipEncoding{}, &buf)
+	assert.Nil(t, enc.Encode(&testUser{Name: "a", Age: 1}))
+	assert.Nil(t, enc.Close())
+	out := &testUser{}
+	assert.Nil(t, JSONGzipEncoding{}.Unmarshal(buf.Bytes(), out))
+	assert.Equal(t, "a", out.Name)
+
+	// 整块编码与流式编码使用相同格式, 可以互相解码
+	for _, name := range []string{"json+gzip", "json+zstd", "json+snappy", "msgpack+gzip", "msgpack+zstd", "msgpack+snappy"} {
+		c := GetCodec(name)
+		data, err := c.Marshal(&testUser{Name: "a", Age: 1})
+		assert.Nil(t, err, name)
+		out = &testUser{}
+		assert.Nil(t, NewDecoder(c, bytes.NewReader(data)).Decode(out), name)
+		assert.Equal(t, "a", out.Name, name)
+
+		buf.Reset()
+		enc = NewEncoder(c, &buf)
+		assert.Nil(t, enc.Encode(&testUser{Name: "b", Age: 2}), name)
+		assert.Nil(t, enc.Close(), name)
+		out = &testUser{}
+		assert.Nil(t, c.Unmarshal(buf.Bytes(), out), name)
+		assert.Equal(t, "b", out.Name, name)
+	}
+
+	// 兼容 snappy block 格式的旧数据
+	plain, _ := JSONEncoding{}.Marshal(&testUser{Name: "c", Age: 3})
+	block := snappy.Encode(nil, plain)
+	out = &testUser{}
+	assert.Nil(t, GetCodec("json+snappy").Unmarshal(block, out))
+	assert.Equal(t, "c", out.Name)
+	out = &testUser{}
+	assert.Nil(t, NewDecoder(GetCodec("json+snappy"), bytes.NewReader(block)).Decode(out))
+	assert.Equal(t, "c", out.Name)
+
+	// protobuf 带长度前缀
+	buf.Reset()
+	enc = NewEncoder(ProtobufEncoding{}, &buf)
+	assert.Nil(t, enc.Encode(wrapperspb.String("a")))
+	assert.Nil(t, enc.Encode(wrapperspb.String("b")))
+	dec := NewDecoder(ProtobufEncoding{}, &buf)
+	for _, want := range []string{"a", "b"} {
+		v := &wrapperspb.StringValue{}
+		assert.Nil(t, dec.Decode(v))
+		assert.Equal(t, want, v.GetValue())
+	}
+}
+
+func benchPayload() []*testUser {
+	users := make([]*testUser, 1000)
+	for i := range users {
+		users[i] = &testUser{Name: strings.Repeat("n", 64), Age: i}
+	}
+	return users
+}
+
+func BenchmarkJSONGzipMarshal(b *testing.B) {
+	users := benchPayload()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, err := JSONGzipEncoding{}.Marshal(users)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, _ = io.Discard.Write(buf)
+	}
+}
+
+func BenchmarkJSONGzipEncoder(b *testing.B) {
+	users := benchPayload()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		enc := NewEncoder(JSONGzipEncoding{}, io.Discard)
+		if err := enc.Encode(users); err != nil {
+			b.Fatal(err)
+		}
+		_ = enc.Close()
+	}
+}
+
+func BenchmarkJSONGzipUnmarshal(b *testing.B) {
+	data, _ := JSONGzipEncoding{}.Marshal(benchPayload())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var users []*testUser
+		if err := (JSONGzipEncoding{}).Unmarshal(data, &users); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJSONGzipDecoder(b *testing.B) {
+	data, _ := JSONGzipEncoding{}.Marshal(benchPayload())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var users []*testUser
+		dec := NewDecoder(JSONGzipEncoding{}, bytes.NewReader(data))
+		if err := dec.Decode(&users); err != nil {
+			b.Fatal(err)
+		}
+		_ = dec.Close()
+	}
+}
+
+func TestRegistryContentType(t *testing.T) {
+	assert.Equal(t, "json", GetCodecByContentType("application/json; charset=utf-8").Name())
+	assert.Equal(t, "json", GetCodecByContentType("application/problem+json").Name())
+	assert.Equal(t, "msgpack", GetCodecByContentType("application/x-msgpack").Name())
+	assert.Equal(t, "proto", GetCodecByContentType("application/x-protobuf").Name())
+	assert.Nil(t, GetCodecByContentType("text/html"))
+	assert.Equal(t, MIMEMsgpack, ContentType(MsgpackEncoding{}))
+}
+
+func TestRegistryNegotiate(t *testing.T) {
+	tests := []struct {
+		accept      string
+		name        string
+		contentType string
+	}{
+		{accept: "", name: "json", contentType: MIMEJSON},
+		{accept: "*/*", name: "json", contentType: MIMEJSON},
+		{accept: "application/x-msgpack", name: "msgpack", contentType: MIMEMsgpack},
+		{accept: "application/json;q=0.5, application/x-protobuf", name: "proto", contentType: MIMEProtobuf},
+		{accept: "text/html, application/msgpack;q=0.9, */*;q=0.1", name: "msgpack", contentType: "application/msgpack"},
+		{accept: "text/html, image/*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			c, contentType := Negotiate(tt.accept)
+			if tt.name == "" {
+				assert.Nil(t, c)
+				return
+			}
+			assert.Equal(t, tt.name, c.Name())
+			assert.Equal(t, tt.contentType, contentType)
+		})
+	}
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	r := NewRegistry(JSONEncoding{})
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			r.Register(MsgpackEncoding{}, MIMEMsgpack)
+		}
+		close(done)
+	}()
+	for i := 0; i < 1000; i++ {
+		r.Get("msgpack")
+		r.Negotiate(MIMEMsgpack)
+	}
+	<-done
+	assert.NotNil(t, r.Get("msgpack"))
+
+	// 自描述编码id注册与解码并发
+	e := NewEnvelopeEncoding(CodecIDJSON)
+	data, err := e.Marshal(&testUser{Name: "a"})
+	assert.Nil(t, err)
+	done = make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			RegisterCodecID(CodecIDJSON, JSONEncoding{})
+		}
+		close(done)
+	}()
+	for i := 0; i < 1000; i++ {
+		assert.Nil(t, e.Unmarshal(data, &testUser{}))
+	}
+	<-done
+}
+
+func TestEncryptEncoding(t *testing.T) {
+	keys := map[string][]byte{
+		"k1": []byte("0123456789abcdef"),
+		"k2": []byte("0123456789abcdef0123456789abcdef"),
+	}
+	old, err := NewEncryptEncoding(JSONEncoding{}, "k1", map[string][]byte{"k1": keys["k1"]})
+	assert.Nil(t, err)
+	e, err := NewEncryptEncoding(JSONEncoding{}, "k2", keys)
+	assert.Nil(t, err)
+
+	in := &testUser{Name: "test-name", Age: 18}
+	data, err := e.Marshal(in)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "test-name")
+
+	// 每次加密使用随机 nonce
+	data2, err := e.Marshal(in)
+	assert.Nil(t, err)
+	assert.NotEqual(t, data, data2)
+
+	out := &testUser{}
+	assert.Nil(t, e.Unmarshal(data, out))
+	assert.Equal(t, in, out)
+
+	// 密钥轮换后旧数据仍可解密
+	oldData, err := old.Marshal(in)
+	assert.Nil(t, err)
+	out = &testUser{}
+	assert.Nil(t, e.Unmarshal(oldData, out))
+	assert.Equal(t, in, out)
+	assert.ErrorIs(t, old.Unmarshal(data, out), ErrUnknownKeyID)
+
+	// 篡改检测
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1] ^= 0xFF
+	assert.ErrorIs(t, e.Unmarshal(tampered, &testUser{}), ErrDecrypt)
+
+	_, err = NewEncryptEncoding(JSONEncoding{}, "k3", keys)
+	assert.ErrorIs(t, err, ErrUnknownKeyID)
+}
+
+func TestEncryptFields(t *testing.T) {
+	type profile struct {
+		Phone string `json:"phone" encrypt:"true"`
+		City  string `json:"city"`
+	}
+	type account struct {
+		Name    string   `json:"name"`
+		IDCard  string   `json:"id_card" encrypt:"true"`
+		Secret  []byte   `json:"secret" encrypt:"true"`
+		Profile *profile `json:"profile"`
+	}
+	e, err := NewEncryptEncoding(JSONEncoding{}, "k1",
+		map[string][]byte{"k1": []byte("0123456789abcdef")}, WithEncryptFields())
+	assert.Nil(t, err)
+
+	in := &account{Name: "test-name", IDCard: "110101", Secret: []byte("s3cret"),
+		Profile: &profile{Phone: "13800000000", City: "Beijing"}}
+	data, err := e.Marshal(in)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "test-name")
+	assert.Contains(t, string(data), "Beijing")
+	assert.NotContains(t, string(data), "110101")
+	assert.NotContains(t, string(data), "13800000000")
+	// 原始数据不被修改
+	assert.Equal(t, "110101", in.IDCard)
+
+	out := &account{}
+	assert.Nil(t, e.Unmarshal(data, out))
+	assert.Equal(t, in, out)
+
+	// 篡改检测
+	raw := map[string]any{}
+	assert.Nil(t, JSONEncoding{}.Unmarshal(data, &raw))
+	buf, _ := base64.StdEncoding.DecodeString(raw["id_card"].(string))
+	buf[len(buf)-1] ^= 0xFF
+	raw["id_card"] = base64.StdEncoding.EncodeToString(buf)
+	tampered, _ := JSONEncoding{}.Marshal(raw)
+	assert.ErrorIs(t, e.Unmarshal(tampered, &account{}), ErrDecrypt)
+}
+
+func TestEncryptFieldsNested(t *testing.T) {
+	type contact struct {
+		Phone string `json:"phone" encrypt:"true"`
+	}
+	type group struct {
+		Members []contact          `json:"members"`
+		Leaders [1]*contact        `json:"leaders"`
+		ByName  map[string]contact `json:"by_name"`
+		Extra   any                `json:"extra"`
+	}
+	e, err := NewEncryptEncoding(JSONEncoding{}, "k1",
+		map[string][]byte{"k1": []byte("0123456789abcdef")}, WithEncryptFields())
+	assert.Nil(t, err)
+
+	in := &group{
+		Members: []contact{{Phone: "13800000001"}, {Phone: "13800000002"}},
+		Leaders: [1]*contact{{Phone: "13800000003"}},
+		ByName:  map[string]contact{"foo": {Phone: "13800000004"}},
+		Extra:   &contact{Phone: "13800000005"},
+	}
+	data, err := e.Marshal(in)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "1380000000")
+	assert.Equal(t, "13800000001", in.Members[0].Phone)
+	assert.Equal(t, "13800000004", in.ByName["foo"].Phone)
+
+	out := &group{Extra: &contact{}}
+	assert.Nil(t, e.Unmarshal(data, out))
+	assert.Equal(t, in, out)
+
+	// 密文移动到其他字段时解密失败
+	raw := map[string]any{}
+	assert.Nil(t, JSONEncoding{}.Unmarshal(data, &raw))
+	members := raw["members"].([]any)
+	members[0], members[1] = members[1], members[0]
+	moved, _ := JSONEncoding{}.Marshal(raw)
+	assert.ErrorIs(t, e.Unmarshal(moved, &group{}), ErrDecrypt)
+
+	// 不支持的类型
+	type invalid struct {
+		Age int `json:"age" encrypt:"true"`
+	}
+	_, err = e.Marshal([]invalid{{Age: 18}})
+	assert.ErrorContains(t, err, "[0].Age")
+	assert.Error(t, e.Unmarshal([]byte(`{"age":18}`), &invalid{}))
+}
@@ -0,0 +1,170 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressor 压缩算法接口定义
+type Compressor interface {
+	// Compress 压缩
+	Compress(in []byte) ([]byte, error)
+	// Decompress 解压
+	Decompress(in []byte) ([]byte, error)
+	// Name 压缩算法名
+	Name() string
+}
+
+// gzipCompressor gzip 压缩
+type gzipCompressor struct {
+	level int
+}
+
+// NewGzipCompressor 实例化gzip压缩, level 取值 gzip.HuffmanOnly ~ gzip.BestCompression
+func NewGzipCompressor(level int) Compressor {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	return &gzipCompressor{level: level}
+}
+
+func (g *gzipCompressor) Compress(in []byte) ([]byte, error) {
+	return GzipEncodeLevel(in, g.level)
+}
+
+func (g *gzipCompressor) Decompress(in []byte) ([]byte, error) {
+	return GzipDecode(in)
+}
+
+func (g *gzipCompressor) Name() string {
+	return "gzip"
+}
+
+// zstdCompressor zstd 压缩, EncodeAll/DecodeAll 可并发调用
+type zstdCompressor struct {
+	once sync.Once
+	enc  *zstd.Encoder
+	dec  *zstd.Decoder
+	err  error
+}
+
+// NewZstdCompressor 实例化zstd压缩
+func NewZstdCompressor() Compressor {
+	return &zstdCompressor{}
+}
+
+func (z *zstdCompressor) init() error {
+	z.once.Do(func() {
+		if z.enc, z.err = zstd.NewWriter(nil); z.err != nil {
+			return
+		}
+		z.dec, z.err = zstd.NewReader(nil)
+	})
+	return z.err
+}
+
+func (z *zstdCompressor) Compress(in []byte) ([]byte, error) {
+	if err := z.init(); err != nil {
+		return nil, err
+	}
+	return z.enc.EncodeAll(in, make([]byte, 0, len(in))), nil
+}
+
+func (z *zstdCompressor) Decompress(in []byte) ([]byte, error) {
+	if err := z.init(); err != nil {
+		return nil, err
+	}
+	return z.dec.DecodeAll(in, nil)
+}
+
+func (z *zstdCompressor) Name() string {
+	return "zstd"
+}
+
+// snappyMagic snappy framing 格式的流标识块
+const snappyMagic = "\xff\x06\x00\x00sNaPpY"
+
+// snappyCompressor snappy 压缩, 整块及流式都使用 framing 格式, 解压时兼容旧的 block 格式
+type snappyCompressor struct{}
+
+// NewSnappyCompressor 实例化snappy压缩
+func NewSnappyCompressor() Compressor {
+	return snappyCompressor{}
+}
+
+func (s snappyCompressor) Compress(in []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := snappy.NewBufferedWriter(&buf)
+	if _, err := w.Write(in); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s snappyCompressor) Decompress(in []byte) ([]byte, error) {
+	if !bytes.HasPrefix(in, []byte(snappyMagic)) {
+		return snappy.Decode(nil, in)
+	}
+	return io.ReadAll(snappy.NewReader(bytes.NewReader(in)))
+}
+
+func (s snappyCompressor) Name() string {
+	return "snappy"
+}
+
+// compressEncoding 在编码结果上再进行压缩
+type compressEncoding struct {
+	codec      Codec
+	compressor Compressor
+}
+
+// NewCompressEncoding 使用指定压缩算法包装codec, 名称为 codec+compressor, 如: msgpack+zstd
+func NewCompressEncoding(c Codec, cp Compressor) Codec {
+	return &compressEncoding{codec: c, compressor: cp}
+}
+
+// WithGzip 使用gzip压缩包装codec
+func WithGzip(c Codec, level int) Codec {
+	return NewCompressEncoding(c, NewGzipCompressor(level))
+}
+
+// WithZstd 使用zstd压缩包装codec
+func WithZstd(c Codec) Codec {
+	return NewCompressEncoding(c, NewZstdCompressor())
+}
+
+// WithSnappy 使用snappy压缩包装codec
+func WithSnappy(c Codec) Codec {
+	return NewCompressEncoding(c, NewSnappyCompressor())
+}
+
+// Marshal encode and compress
+func (c *compressEncoding) Marshal(v any) ([]byte, error) {
+	buf, err := c.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return c.compressor.Compress(buf)
+}
+
+// Unmarshal decompress and decode
+func (c *compressEncoding) Unmarshal(data []byte, value any) error {
+	buf, err := c.compressor.Decompress(data)
+	if err != nil {
+		return err
+	}
+	return c.codec.Unmarshal(buf, value)
+}
+
+// Name 编码名
+func (c *compressEncoding) Name() string {
+	return c.codec.Name() + "+" + c.compressor.Name()
+}
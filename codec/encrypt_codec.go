@@ -0,0 +1,401 @@
+package codec
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// 加密数据格式:
+//
+//	+---------+------------+--------+-----------+------------+
+//	| version | key id len | key id | nonce 12B | ciphertext |
+//	+---------+------------+--------+-----------+------------+
+//
+// 使用 AES-GCM 加密, 每个值使用随机 nonce, version, key id 及字段路径作为附加数据, 密文被篡改或移动时解密失败
+// 解密时按 key id 选择密钥, 密钥轮换后旧数据仍可解密
+const (
+	encryptVersion   byte = 1
+	encryptNonceSize      = 12
+	// EncryptTag 字段加密模式下需要加密的字段标签, 如: `encrypt:"true"`
+	EncryptTag = "encrypt"
+)
+
+var (
+	// ErrDecrypt 解密失败, 数据被篡改或密钥错误
+	ErrDecrypt = errors.New("codec: decrypt failed")
+	// ErrUnknownKeyID 密钥id不存在
+	ErrUnknownKeyID = errors.New("codec: unknown encrypt key id")
+)
+
+// EncryptOption 加密编码选项
+type EncryptOption func(e *EncryptEncoding)
+
+// WithEncryptFields 只加密带有 `encrypt:"true"` 标签的 string/[]byte 字段, 其余字段明文编码,
+// 标签用于其他类型时编码返回错误, 切片, 数组, map 及接口中的结构体同样处理
+func WithEncryptFields() EncryptOption {
+	return func(e *EncryptEncoding) {
+		e.fields = true
+	}
+}
+
+// EncryptEncoding 使用 AES-GCM 加密编码结果
+type EncryptEncoding struct {
+	codec  Codec
+	keyID  string
+	keys   map[string][]byte
+	fields bool
+}
+
+// NewEncryptEncoding 实例化加密编码, keyID 为当前加密使用的密钥, keys 为所有可用于解密的密钥
+// 密钥长度只能是16、24、32字节，用以选择AES-128、AES-192、AES-256
+func NewEncryptEncoding(c Codec, keyID string, keys map[string][]byte, opts ...EncryptOption) (*EncryptEncoding, error) {
+	if _, ok := keys[keyID]; !ok {
+		return nil, ErrUnknownKeyID
+	}
+	for id, key := range keys {
+		if len(id) > 255 {
+			return nil, fmt.Errorf("codec: encrypt key id %q too long", id)
+		}
+		switch len(key) {
+		case 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("codec: invalid encrypt key size %d, key id %q", len(key), id)
+		}
+	}
+
+	e := &EncryptEncoding{codec: c, keyID: keyID, keys: keys}
+	for _, o := range opts {
+		o(e)
+	}
+	return e, nil
+}
+
+// Marshal encode and encrypt
+func (e *EncryptEncoding) Marshal(v any) ([]byte, error) {
+	if e.fields {
+		ev, err := e.encryptFields(reflect.ValueOf(v), "")
+		if err != nil {
+			return nil, err
+		}
+		return e.codec.Marshal(ev.Interface())
+	}
+
+	buf, err := e.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return e.encrypt(buf, "")
+}
+
+// Unmarshal decrypt and decode
+func (e *EncryptEncoding) Unmarshal(data []byte, value any) error {
+	if e.fields {
+		if err := e.codec.Unmarshal(data, value); err != nil {
+			return err
+		}
+		return e.decryptFields(reflect.ValueOf(value), "")
+	}
+
+	buf, err := e.decrypt(data, "")
+	if err != nil {
+		return err
+	}
+	return e.codec.Unmarshal(buf, value)
+}
+
+// Name 编码名
+func (e *EncryptEncoding) Name() string {
+	return "encrypt+" + e.codec.Name()
+}
+
+// encrypt 使用当前密钥加密, key id 及字段路径 path 作为附加数据参与认证
+func (e *EncryptEncoding) encrypt(plain []byte, path string) ([]byte, error) {
+	nonce := make([]byte, encryptNonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(e.keys[e.keyID])
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, 2+len(e.keyID)+encryptNonceSize+len(plain)+aead.Overhead())
+	buf = append(buf, encryptVersion, byte(len(e.keyID)))
+	buf = append(buf, e.keyID...)
+	header := len(buf)
+	buf = append(buf, nonce...)
+	return aead.Seal(buf, nonce, plain, additionalData(buf[:header], path)), nil
+}
+
+// decrypt 根据数据中的 key id 选择密钥解密, path 需要与加密时一致
+func (e *EncryptEncoding) decrypt(data []byte, path string) ([]byte, error) {
+	if len(data) < 2 || data[0] != encryptVersion {
+		return nil, ErrDecrypt
+	}
+	idLen := int(data[1])
+	if len(data) < 2+idLen+encryptNonceSize {
+		return nil, ErrDecrypt
+	}
+	key, ok := e.keys[string(data[2:2+idLen])]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := data[2+idLen : 2+idLen+encryptNonceSize]
+	plain, err := aead.Open(nil, nonce, data[2+idLen+encryptNonceSize:], additionalData(data[:2+idLen], path))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData 附加数据为 version, key id 及字段路径, 密文被移动到其他字段或替换 key id 时解密失败
+func additionalData(header []byte, path string) []byte {
+	ad := make([]byte, 0, len(header)+len(path))
+	ad = append(ad, header...)
+	return append(ad, path...)
+}
+
+// encryptFields 复制 v 并加密带标签的字段, 不修改原始数据
+// 递归处理指针, 接口, 结构体, 切片, 数组及 map, path 为字段路径, 如: Users[0].Profile.Phone
+func (e *EncryptEncoding) encryptFields(v reflect.Value, path string) (reflect.Value, error) {
+	if !v.IsValid() || !mayEncrypt(v.Type()) {
+		return v, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, nil
+		}
+		ev, err := e.encryptFields(v.Elem(), path)
+		if err != nil {
+			return v, err
+		}
+		p := reflect.New(ev.Type())
+		p.Elem().Set(ev)
+		return p, nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, nil
+		}
+		ev, err := e.encryptFields(v.Elem(), path)
+		if err != nil {
+			return v, err
+		}
+		nv := reflect.New(v.Type()).Elem()
+		nv.Set(ev)
+		return nv, nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return v, nil
+		}
+		nv := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		return nv, e.encryptElems(v, nv, path)
+	case reflect.Array:
+		nv := reflect.New(v.Type()).Elem()
+		return nv, e.encryptElems(v, nv, path)
+	case reflect.Map:
+		if v.IsNil() {
+			return v, nil
+		}
+		nv := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			ev, err := e.encryptFields(iter.Value(), indexPath(path, iter.Key().Interface()))
+			if err != nil {
+				return v, err
+			}
+			nv.SetMapIndex(iter.Key(), ev)
+		}
+		return nv, nil
+	}
+
+	nv := reflect.New(v.Type()).Elem()
+	nv.Set(v)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := nv.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		fp := fieldPath(path, t.Field(i).Name)
+		if t.Field(i).Tag.Get(EncryptTag) != "true" {
+			ef, err := e.encryptFields(f, fp)
+			if err != nil {
+				return v, err
+			}
+			f.Set(ef)
+			continue
+		}
+
+		switch {
+		case f.Kind() == reflect.String:
+			buf, err := e.encrypt([]byte(f.String()), fp)
+			if err != nil {
+				return v, err
+			}
+			f.SetString(base64.StdEncoding.EncodeToString(buf))
+		case isBytes(f.Type()):
+			buf, err := e.encrypt(f.Bytes(), fp)
+			if err != nil {
+				return v, err
+			}
+			f.SetBytes(buf)
+		default:
+			return v, unsupportedField(fp, f.Type())
+		}
+	}
+	return nv, nil
+}
+
+// encryptElems 加密切片或数组 v 的元素到 nv
+func (e *EncryptEncoding) encryptElems(v, nv reflect.Value, path string) error {
+	for i := 0; i < v.Len(); i++ {
+		ev, err := e.encryptFields(v.Index(i), indexPath(path, i))
+		if err != nil {
+			return err
+		}
+		nv.Index(i).Set(ev)
+	}
+	return nil
+}
+
+// decryptFields 原地解密带标签的字段, 字段路径与 encryptFields 一致
+func (e *EncryptEncoding) decryptFields(v reflect.Value, path string) error {
+	if !v.IsValid() || !mayEncrypt(v.Type()) {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return e.decryptFields(v.Elem(), path)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		ev := v.Elem()
+		if ev.Kind() == reflect.Ptr || !v.CanSet() {
+			return e.decryptFields(ev, path)
+		}
+		// 接口中的值不可寻址, 复制后解密再写回
+		nv := reflect.New(ev.Type()).Elem()
+		nv.Set(ev)
+		if err := e.decryptFields(nv, path); err != nil {
+			return err
+		}
+		v.Set(nv)
+		return nil
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := e.decryptFields(v.Index(i), indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			nv := reflect.New(v.Type().Elem()).Elem()
+			nv.Set(iter.Value())
+			if err := e.decryptFields(nv, indexPath(path, iter.Key().Interface())); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), nv)
+		}
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := v.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		fp := fieldPath(path, t.Field(i).Name)
+		if t.Field(i).Tag.Get(EncryptTag) != "true" {
+			if err := e.decryptFields(f, fp); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch {
+		case f.Kind() == reflect.String:
+			if f.String() == "" {
+				continue
+			}
+			buf, err := base64.StdEncoding.DecodeString(f.String())
+			if err != nil {
+				return ErrDecrypt
+			}
+			plain, err := e.decrypt(buf, fp)
+			if err != nil {
+				return err
+			}
+			f.SetString(string(plain))
+		case isBytes(f.Type()):
+			if f.Len() == 0 {
+				continue
+			}
+			plain, err := e.decrypt(f.Bytes(), fp)
+			if err != nil {
+				return err
+			}
+			f.SetBytes(plain)
+		default:
+			return unsupportedField(fp, f.Type())
+		}
+	}
+	return nil
+}
+
+// mayEncrypt 类型中是否可能包含加密字段
+func mayEncrypt(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return mayEncrypt(t.Elem())
+	}
+	return false
+}
+
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func indexPath(path string, key any) string {
+	return fmt.Sprintf("%s[%v]", path, key)
+}
+
+func unsupportedField(path string, t reflect.Type) error {
+	return fmt.Errorf("codec: encrypt field %s of type %s, only string and []byte are supported", path, t)
+}
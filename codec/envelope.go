@@ -0,0 +1,194 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 自描述编码格式:
+//
+//	+-------+----------+-------------+---------+---------+
+//	| magic | codec id | compression | version | payload |
+//	+-------+----------+-------------+---------+---------+
+//	|  1B   |    1B    |     1B      |   1B    |   ...   |
+//
+// 解码时根据头部自动选择编码及压缩算法, 没有头部的数据视为旧格式(默认json, 兼容gzip),
+// 这样切换编码时可以在线迁移并读取新旧混合数据
+const (
+	// EnvelopeMagic 自描述编码魔数
+	EnvelopeMagic byte = 0xEE
+	// envelopeHeaderSize 头部长度
+	envelopeHeaderSize = 4
+)
+
+// 编码id
+const (
+	CodecIDJSON    byte = 1
+	CodecIDGob     byte = 2
+	CodecIDMsgpack byte = 3
+	CodecIDProto   byte = 4
+)
+
+// 压缩算法id
+const (
+	CompressNone   byte = 0
+	CompressGzip   byte = 1
+	CompressZstd   byte = 2
+	CompressSnappy byte = 3
+)
+
+var (
+	// ErrUnknownCodecID .
+	ErrUnknownCodecID = errors.New("codec: unknown envelope codec id")
+	// ErrUnknownCompression .
+	ErrUnknownCompression = errors.New("codec: unknown envelope compression")
+)
+
+var envelopeCompressors = map[byte]Compressor{
+	CompressGzip:   NewGzipCompressor(-1),
+	CompressZstd:   NewZstdCompressor(),
+	CompressSnappy: NewSnappyCompressor(),
+}
+
+// RegisterCodecID 注册编码id, 用于自描述编码中识别编码
+//
+// NOTE: this function is safe for concurrent use.  If multiple Codecs are
+// registered with the same id, the one registered last will take effect.
+func RegisterCodecID(id byte, c Codec) {
+	defaultRegistry.RegisterID(id, c)
+}
+
+// Header 自描述编码头部
+type Header struct {
+	CodecID     byte
+	Compression byte
+	Version     uint8
+}
+
+// ParseHeader 解析头部, 非自描述格式时 ok 为 false
+func ParseHeader(data []byte) (h Header, ok bool) {
+	if len(data) < envelopeHeaderSize || data[0] != EnvelopeMagic {
+		return h, false
+	}
+	return Header{CodecID: data[1], Compression: data[2], Version: data[3]}, true
+}
+
+// EnvelopeOption 自描述编码选项
+type EnvelopeOption func(e *EnvelopeEncoding)
+
+// WithEnvelopeCompress 设置写入时使用的压缩算法
+func WithEnvelopeCompress(compression byte) EnvelopeOption {
+	return func(e *EnvelopeEncoding) {
+		e.compression = compression
+	}
+}
+
+// WithEnvelopeVersion 设置写入时的数据结构版本
+func WithEnvelopeVersion(version uint8) EnvelopeOption {
+	return func(e *EnvelopeEncoding) {
+		e.version = version
+	}
+}
+
+// WithEnvelopeLegacy 设置旧格式(无头部)数据的编码, 默认json
+func WithEnvelopeLegacy(c Encoding) EnvelopeOption {
+	return func(e *EnvelopeEncoding) {
+		e.legacy = c
+	}
+}
+
+// EnvelopeEncoding 自描述编码
+type EnvelopeEncoding struct {
+	codecID     byte
+	compression byte
+	version     uint8
+	legacy      Encoding
+}
+
+// NewEnvelopeEncoding 实例化自描述编码, codecID 为写入时使用的编码
+func NewEnvelopeEncoding(codecID byte, opts ...EnvelopeOption) *EnvelopeEncoding {
+	e := &EnvelopeEncoding{
+		codecID: codecID,
+		legacy:  JSONEncoding{},
+	}
+	for _, o := range opts {
+		o(e)
+	}
+	if defaultRegistry.ByID(e.codecID) == nil {
+		panic(fmt.Sprintf("codec: envelope codec id %d not registered", codecID))
+	}
+	if _, ok := envelopeCompressors[e.compression]; !ok && e.compression != CompressNone {
+		panic(fmt.Sprintf("codec: envelope compression %d not supported", e.compression))
+	}
+	return e
+}
+
+// Marshal encode with header
+func (e *EnvelopeEncoding) Marshal(v any) ([]byte, error) {
+	payload, err := defaultRegistry.ByID(e.codecID).Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if e.compression != CompressNone {
+		if payload, err = envelopeCompressors[e.compression].Compress(payload); err != nil {
+			return nil, err
+		}
+	}
+
+	buf := make([]byte, envelopeHeaderSize, envelopeHeaderSize+len(payload))
+	buf[0], buf[1], buf[2], buf[3] = EnvelopeMagic, e.codecID, e.compression, e.version
+	return append(buf, payload...), nil
+}
+
+// Unmarshal 自动识别格式解码
+func (e *EnvelopeEncoding) Unmarshal(data []byte, value any) error {
+	_, err := e.UnmarshalVersion(data, value)
+	return err
+}
+
+// UnmarshalVersion 自动识别格式解码, 并返回数据结构版本, 旧格式版本为0
+func (e *EnvelopeEncoding) UnmarshalVersion(data []byte, value any) (uint8, error) {
+	h, ok := ParseHeader(data)
+	if !ok {
+		return 0, e.unmarshalLegacy(data, value)
+	}
+	c := defaultRegistry.ByID(h.CodecID)
+	if c == nil {
+		return h.Version, ErrUnknownCodecID
+	}
+
+	payload := data[envelopeHeaderSize:]
+	if h.Compression != CompressNone {
+		cp, exist := envelopeCompressors[h.Compression]
+		if !exist {
+			return h.Version, ErrUnknownCompression
+		}
+		var err error
+		if payload, err = cp.Decompress(payload); err != nil {
+			return h.Version, err
+		}
+	}
+
+	return h.Version, c.Unmarshal(payload, value)
+}
+
+// unmarshalLegacy 解码旧格式数据, gzip 数据(如 JSONGzipEncoding)先解压
+func (e *EnvelopeEncoding) unmarshalLegacy(data []byte, value any) error {
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		buf, err := GzipDecode(data)
+		if err != nil {
+			return err
+		}
+		data = buf
+	}
+	return e.legacy.Unmarshal(data, value)
+}
+
+// Name 编码名
+func (e *EnvelopeEncoding) Name() string {
+	name := "envelope+" + defaultRegistry.ByID(e.codecID).Name()
+	if cp, ok := envelopeCompressors[e.compression]; ok {
+		name += "+" + cp.Name()
+	}
+	return name
+}
@@ -0,0 +1,28 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// GobEncoding gob格式
+type GobEncoding struct{}
+
+// Marshal gob encode
+func (g GobEncoding) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal gob decode
+func (g GobEncoding) Unmarshal(data []byte, value any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(value)
+}
+
+// Name 编码名
+func (g GobEncoding) Name() string {
+	return "gob"
+}
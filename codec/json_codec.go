@@ -21,6 +21,11 @@ func (j JSONEncoding) Unmarshal(data []byte, value any) error {
 	return json.Unmarshal(data, value)
 }
 
+// Name 编码名
+func (j JSONEncoding) Name() string {
+	return "json"
+}
+
 // JSONGzipEncoding json and gzip
 type JSONGzipEncoding struct{}
 
@@ -51,14 +56,24 @@ func (jz JSONGzipEncoding) Unmarshal(data []byte, value any) error {
 	return nil
 }
 
-// GzipEncode 编码
+// Name 编码名
+func (jz JSONGzipEncoding) Name() string {
+	return "json+gzip"
+}
+
+// GzipEncode 编码, 使用最高压缩级别
 func GzipEncode(in []byte) ([]byte, error) {
+	return GzipEncodeLevel(in, gzip.BestCompression)
+}
+
+// GzipEncodeLevel 指定压缩级别编码
+func GzipEncodeLevel(in []byte, level int) ([]byte, error) {
 	var (
 		buffer bytes.Buffer
 		out    []byte
 		err    error
 	)
-	writer, err := gzip.NewWriterLevel(&buffer, gzip.BestCompression)
+	writer, err := gzip.NewWriterLevel(&buffer, level)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,25 @@
+package codec
+
+import (
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// docs: https://msgpack.uptrace.dev/
+
+// MsgpackEncoding msgpack格式
+type MsgpackEncoding struct{}
+
+// Marshal msgpack encode
+func (m MsgpackEncoding) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal msgpack decode
+func (m MsgpackEncoding) Unmarshal(data []byte, value any) error {
+	return msgpack.Unmarshal(data, value)
+}
+
+// Name 编码名
+func (m MsgpackEncoding) Name() string {
+	return "msgpack"
+}
@@ -0,0 +1,38 @@
+package codec
+
+import (
+	"errors"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrNotProtoMessage .
+	ErrNotProtoMessage = errors.New("v argument must be a proto.Message")
+)
+
+// ProtobufEncoding protobuf格式, 仅支持 proto.Message
+type ProtobufEncoding struct{}
+
+// Marshal protobuf encode
+func (p ProtobufEncoding) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, ErrNotProtoMessage
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal protobuf decode
+func (p ProtobufEncoding) Unmarshal(data []byte, value any) error {
+	m, ok := value.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// Name 编码名
+func (p ProtobufEncoding) Name() string {
+	return "proto"
+}
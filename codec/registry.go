@@ -0,0 +1,247 @@
+package codec
+
+import (
+	"compress/gzip"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 常用 MIME 类型
+const (
+	MIMEJSON     = "application/json"
+	MIMEMsgpack  = "application/x-msgpack"
+	MIMEProtobuf = "application/x-protobuf"
+	MIMEGob      = "application/x-gob"
+)
+
+// Registry 编码注册表, 按名称及 MIME 类型查找编码, 可并发使用
+type Registry struct {
+	mu           sync.RWMutex
+	codecs       map[string]Codec  // name -> codec
+	contentTypes map[string]Codec  // mime -> codec
+	primary      map[string]string // name -> 首选 mime
+	ids          map[byte]Codec    // 自描述编码 id -> codec
+	def          Codec             // 协商时的默认编码
+}
+
+// NewRegistry 实例化编码注册表, def 为 Accept 为空或通配时使用的默认编码
+func NewRegistry(def Codec) *Registry {
+	return &Registry{
+		codecs:       make(map[string]Codec),
+		contentTypes: make(map[string]Codec),
+		primary:      make(map[string]string),
+		ids:          make(map[byte]Codec),
+		def:          def,
+	}
+}
+
+// Register 注册编码, contentTypes 为该编码对应的 MIME 类型, 第一个为首选类型
+// 同名编码后注册的生效
+func (r *Registry) Register(c Codec, contentTypes ...string) {
+	if c == nil {
+		panic("cannot register a nil Codec")
+	}
+	if c.Name() == "" {
+		panic("cannot register Codec with empty string result for Name()")
+	}
+	name := strings.ToLower(c.Name())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codecs[name] = c
+	for i, ct := range contentTypes {
+		ct = strings.ToLower(ct)
+		r.contentTypes[ct] = c
+		if i == 0 {
+			r.primary[name] = ct
+		}
+	}
+}
+
+// RegisterID 注册自描述编码使用的编码id, 同一id后注册的生效
+func (r *Registry) RegisterID(id byte, c Codec) {
+	if c == nil {
+		panic("cannot register a nil Codec")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids[id] = c
+}
+
+// ByID 按自描述编码id获取编码, 不存在时返回 nil
+func (r *Registry) ByID(id byte) Codec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ids[id]
+}
+
+// Get 按名称获取编码, 不存在时返回 nil
+func (r *Registry) Get(name string) Codec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.codecs[strings.ToLower(name)]
+}
+
+// ForContentType 按 Content-Type 获取编码, 忽略参数(如 charset),
+// 支持结构化后缀, 如 application/problem+json 对应 json 编码
+func (r *Registry) ForContentType(contentType string) Codec {
+	mediaType := parseMediaType(contentType)
+	if mediaType == "" {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if c, ok := r.contentTypes[mediaType]; ok {
+		return c
+	}
+	if i := strings.LastIndexByte(mediaType, '+'); i > 0 {
+		return r.codecs[mediaType[i+1:]]
+	}
+	return nil
+}
+
+// ContentType 获取编码的首选 MIME 类型, 未设置时返回空
+func (r *Registry) ContentType(c Codec) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.primary[strings.ToLower(c.Name())]
+}
+
+// Negotiate 根据 Accept 头协商编码, 返回编码及响应的 Content-Type
+// 按 q 值从高到低匹配, Accept 为空或通配时返回默认编码, 无法匹配时返回 nil
+func (r *Registry) Negotiate(accept string) (Codec, string) {
+	if strings.TrimSpace(accept) == "" {
+		return r.defaultCodec()
+	}
+	for _, mediaType := range parseAccept(accept) {
+		switch {
+		case mediaType == "*/*" || mediaType == "application/*":
+			return r.defaultCodec()
+		case strings.HasSuffix(mediaType, "/*"):
+			continue
+		}
+		if c := r.ForContentType(mediaType); c != nil {
+			return c, mediaType
+		}
+	}
+	return nil, ""
+}
+
+func (r *Registry) defaultCodec() (Codec, string) {
+	if r.def == nil {
+		return nil, ""
+	}
+	return r.def, r.ContentType(r.def)
+}
+
+// parseMediaType 解析 MIME 类型, 去除参数并转为小写
+func parseMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// parseAccept 解析 Accept 头, 按 q 值降序返回 MIME 类型, q=0 的类型被忽略
+func parseAccept(accept string) []string {
+	type item struct {
+		mediaType string
+		q         float64
+	}
+	items := make([]item, 0, 4)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if mediaType == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if ok && strings.TrimSpace(k) == "q" {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if q > 0 {
+			items = append(items, item{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].q > items[j].q
+	})
+
+	types := make([]string, len(items))
+	for i, it := range items {
+		types[i] = it.mediaType
+	}
+	return types
+}
+
+// defaultRegistry 全局编码注册表
+var defaultRegistry = NewRegistry(JSONEncoding{})
+
+func init() {
+	RegisterCodec(JSONEncoding{}, MIMEJSON, "text/json")
+	RegisterCodec(MsgpackEncoding{}, MIMEMsgpack, "application/msgpack", "application/vnd.msgpack")
+	RegisterCodec(ProtobufEncoding{}, MIMEProtobuf, "application/protobuf", "application/vnd.google.protobuf")
+	RegisterCodec(GobEncoding{}, MIMEGob)
+
+	RegisterCodecID(CodecIDJSON, JSONEncoding{})
+	RegisterCodecID(CodecIDGob, GobEncoding{})
+	RegisterCodecID(CodecIDMsgpack, MsgpackEncoding{})
+	RegisterCodecID(CodecIDProto, ProtobufEncoding{})
+
+	bases := []Codec{JSONEncoding{}, GobEncoding{}, MsgpackEncoding{}, ProtobufEncoding{}}
+	for _, c := range bases {
+		// 默认压缩组合, 如: json+gzip, msgpack+zstd, proto+snappy
+		RegisterCodec(WithGzip(c, gzip.DefaultCompression))
+		RegisterCodec(WithZstd(c))
+		RegisterCodec(WithSnappy(c))
+	}
+}
+
+// RegisterCodec registers the provided Codec for use with all transport clients and
+// servers.
+//
+// The Codec will be stored and looked up by result of its Name() method, which
+// should match the content-subtype of the encoding handled by the Codec.  This
+// is case-insensitive, and is stored and looked up as lowercase.  If the
+// result of calling Name() is an empty string, RegisterCodec will panic.
+// contentTypes are the MIME types handled by the Codec, the first one is used
+// as the response Content-Type.
+//
+// NOTE: this function is safe for concurrent use.  If multiple Codecs are
+// registered with the same name, the one registered last will take effect.
+func RegisterCodec(codec Codec, contentTypes ...string) {
+	defaultRegistry.Register(codec, contentTypes...)
+}
+
+// GetCodec gets a registered Codec by content-subtype, or nil if no Codec is
+// registered for the content-subtype.
+func GetCodec(contentSubtype string) Codec {
+	return defaultRegistry.Get(contentSubtype)
+}
+
+// GetCodecByContentType gets a registered Codec by MIME type, or nil if no
+// Codec is registered for the MIME type.
+func GetCodecByContentType(contentType string) Codec {
+	return defaultRegistry.ForContentType(contentType)
+}
+
+// ContentType returns the preferred MIME type of the Codec.
+func ContentType(c Codec) string {
+	return defaultRegistry.ContentType(c)
+}
+
+// Negotiate picks a registered Codec by the Accept header.
+func Negotiate(accept string) (Codec, string) {
+	return defaultRegistry.Negotiate(accept)
+}
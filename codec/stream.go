@@ -0,0 +1,396 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+)
+
+// Encoder 流式编码器
+type Encoder interface {
+	// Encode 编码并写入
+	Encode(v any) error
+	// Close 刷新缓冲并释放资源, 不会关闭底层 io.Writer
+	Close() error
+}
+
+// Decoder 流式解码器
+type Decoder interface {
+	// Decode 读取并解码
+	Decode(v any) error
+	// Close 释放资源, 不会关闭底层 io.Reader
+	Close() error
+}
+
+// StreamCodec 支持流式编解码的 Codec
+type StreamCodec interface {
+	Codec
+	Encoder(w io.Writer) Encoder
+	Decoder(r io.Reader) Decoder
+}
+
+// StreamCompressor 支持流式压缩的 Compressor
+type StreamCompressor interface {
+	Compressor
+	// Writer 返回压缩写入器, Close 时刷新数据, 不会关闭 w
+	Writer(w io.Writer) io.WriteCloser
+	// Reader 返回解压读取器, Close 时释放资源, 不会关闭 r
+	Reader(r io.Reader) (io.ReadCloser, error)
+}
+
+// NewEncoder 获取流式编码器, codec 不支持流式时退化为整块编码后写入
+func NewEncoder(c Codec, w io.Writer) Encoder {
+	if sc, ok := c.(StreamCodec); ok {
+		return sc.Encoder(w)
+	}
+	return &funcEncoder{encode: func(v any) error {
+		buf, err := c.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buf)
+		return err
+	}}
+}
+
+// NewDecoder 获取流式解码器, codec 不支持流式时退化为读取全部数据后解码
+func NewDecoder(c Codec, r io.Reader) Decoder {
+	if sc, ok := c.(StreamCodec); ok {
+		return sc.Decoder(r)
+	}
+	return &funcDecoder{decode: func(v any) error {
+		buf, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return c.Unmarshal(buf, v)
+	}}
+}
+
+// ErrStreamClosed 编解码器已关闭
+var ErrStreamClosed = errors.New("codec: encoder or decoder closed")
+
+// funcEncoder Close 可重复调用, 资源只释放一次
+type funcEncoder struct {
+	encode func(v any) error
+	close  func() error
+	closed bool
+}
+
+func (e *funcEncoder) Encode(v any) error {
+	if e.closed {
+		return ErrStreamClosed
+	}
+	return e.encode(v)
+}
+
+func (e *funcEncoder) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	if e.close == nil {
+		return nil
+	}
+	return e.close()
+}
+
+// funcDecoder Close 可重复调用, 资源只释放一次
+type funcDecoder struct {
+	decode func(v any) error
+	close  func() error
+	closed bool
+}
+
+func (d *funcDecoder) Decode(v any) error {
+	if d.closed {
+		return ErrStreamClosed
+	}
+	return d.decode(v)
+}
+
+func (d *funcDecoder) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	if d.close == nil {
+		return nil
+	}
+	return d.close()
+}
+
+// Encoder json 流式编码
+func (j JSONEncoding) Encoder(w io.Writer) Encoder {
+	return &funcEncoder{encode: json.NewEncoder(w).Encode}
+}
+
+// Decoder json 流式解码
+func (j JSONEncoding) Decoder(r io.Reader) Decoder {
+	return &funcDecoder{decode: json.NewDecoder(r).Decode}
+}
+
+// Encoder json and gzip 流式编码
+func (jz JSONGzipEncoding) Encoder(w io.Writer) Encoder {
+	return compressEncoder(JSONEncoding{}, &gzipCompressor{level: gzip.BestCompression}, w)
+}
+
+// Decoder json and gzip 流式解码
+func (jz JSONGzipEncoding) Decoder(r io.Reader) Decoder {
+	return compressDecoder(JSONEncoding{}, &gzipCompressor{}, r)
+}
+
+// Encoder gob 流式编码
+func (g GobEncoding) Encoder(w io.Writer) Encoder {
+	return &funcEncoder{encode: gob.NewEncoder(w).Encode}
+}
+
+// Decoder gob 流式解码
+func (g GobEncoding) Decoder(r io.Reader) Decoder {
+	return &funcDecoder{decode: gob.NewDecoder(r).Decode}
+}
+
+// Encoder msgpack 流式编码
+func (m MsgpackEncoding) Encoder(w io.Writer) Encoder {
+	enc := msgpack.GetEncoder()
+	enc.Reset(w)
+	return &funcEncoder{encode: enc.Encode, close: func() error {
+		msgpack.PutEncoder(enc)
+		return nil
+	}}
+}
+
+// Decoder msgpack 流式解码
+func (m MsgpackEncoding) Decoder(r io.Reader) Decoder {
+	dec := msgpack.GetDecoder()
+	dec.Reset(r)
+	return &funcDecoder{decode: dec.Decode, close: func() error {
+		msgpack.PutDecoder(dec)
+		return nil
+	}}
+}
+
+// Encoder protobuf 流式编码, 每条消息带有长度前缀
+func (p ProtobufEncoding) Encoder(w io.Writer) Encoder {
+	return &funcEncoder{encode: func(v any) error {
+		m, ok := v.(proto.Message)
+		if !ok {
+			return ErrNotProtoMessage
+		}
+		_, err := protodelim.MarshalTo(w, m)
+		return err
+	}}
+}
+
+// Decoder protobuf 流式解码, 每条消息带有长度前缀
+func (p ProtobufEncoding) Decoder(r io.Reader) Decoder {
+	br, ok := r.(protodelim.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &funcDecoder{decode: func(v any) error {
+		m, ok := v.(proto.Message)
+		if !ok {
+			return ErrNotProtoMessage
+		}
+		return protodelim.UnmarshalFrom(br, m)
+	}}
+}
+
+// Encoder 流式编码并压缩
+func (c *compressEncoding) Encoder(w io.Writer) Encoder {
+	return compressEncoder(c.codec, c.compressor, w)
+}
+
+// Decoder 流式解压并解码
+func (c *compressEncoding) Decoder(r io.Reader) Decoder {
+	return compressDecoder(c.codec, c.compressor, r)
+}
+
+func compressEncoder(c Codec, cp Compressor, w io.Writer) Encoder {
+	sc, ok := cp.(StreamCompressor)
+	if !ok {
+		return NewEncoder(NewCompressEncoding(c, cp), w)
+	}
+	cw := sc.Writer(w)
+	enc := NewEncoder(c, cw)
+	return &funcEncoder{encode: enc.Encode, close: func() error {
+		if err := enc.Close(); err != nil {
+			cw.Close()
+			return err
+		}
+		return cw.Close()
+	}}
+}
+
+func compressDecoder(c Codec, cp Compressor, r io.Reader) Decoder {
+	sc, ok := cp.(StreamCompressor)
+	if !ok {
+		return NewDecoder(NewCompressEncoding(c, cp), r)
+	}
+	var (
+		cr  io.ReadCloser
+		dec Decoder
+		err error
+	)
+	// 延迟创建, 解压头部读取错误在 Decode 时返回
+	return &funcDecoder{decode: func(v any) error {
+		if dec == nil && err == nil {
+			if cr, err = sc.Reader(r); err == nil {
+				dec = NewDecoder(c, cr)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		return dec.Decode(v)
+	}, close: func() error {
+		if dec == nil {
+			return nil
+		}
+		dec.Close()
+		return cr.Close()
+	}}
+}
+
+var (
+	// gzipWriterPools 按压缩级别缓存 gzip.Writer, 下标为 level - gzip.HuffmanOnly
+	gzipWriterPools [gzip.BestCompression - gzip.HuffmanOnly + 1]sync.Pool
+	gzipReaderPool  sync.Pool
+)
+
+type gzipWriter struct {
+	*gzip.Writer
+	level int
+}
+
+// Close 刷新数据后放回池中, 重复调用时直接返回
+func (g *gzipWriter) Close() error {
+	if g.Writer == nil {
+		return nil
+	}
+	zw := g.Writer
+	g.Writer = nil
+	err := zw.Close()
+	zw.Reset(nil)
+	gzipWriterPools[g.level-gzip.HuffmanOnly].Put(zw)
+	return err
+}
+
+// Write 关闭后返回 ErrStreamClosed
+func (g *gzipWriter) Write(p []byte) (int, error) {
+	if g.Writer == nil {
+		return 0, ErrStreamClosed
+	}
+	return g.Writer.Write(p)
+}
+
+type gzipReader struct {
+	*gzip.Reader
+}
+
+// Close 放回池中, 重复调用时直接返回
+func (g *gzipReader) Close() error {
+	if g.Reader == nil {
+		return nil
+	}
+	zr := g.Reader
+	g.Reader = nil
+	err := zr.Close()
+	gzipReaderPool.Put(zr)
+	return err
+}
+
+// Read 关闭后返回 ErrStreamClosed
+func (g *gzipReader) Read(p []byte) (int, error) {
+	if g.Reader == nil {
+		return 0, ErrStreamClosed
+	}
+	return g.Reader.Read(p)
+}
+
+// Writer gzip 压缩写入器, 使用池化的 gzip.Writer
+func (g *gzipCompressor) Writer(w io.Writer) io.WriteCloser {
+	if zw, ok := gzipWriterPools[g.level-gzip.HuffmanOnly].Get().(*gzip.Writer); ok {
+		zw.Reset(w)
+		return &gzipWriter{Writer: zw, level: g.level}
+	}
+	// level 已在 NewGzipCompressor 中校验
+	zw, _ := gzip.NewWriterLevel(w, g.level)
+	return &gzipWriter{Writer: zw, level: g.level}
+}
+
+// Reader gzip 解压读取器, 使用池化的 gzip.Reader
+func (g *gzipCompressor) Reader(r io.Reader) (io.ReadCloser, error) {
+	if zr, ok := gzipReaderPool.Get().(*gzip.Reader); ok {
+		if err := zr.Reset(r); err != nil {
+			gzipReaderPool.Put(zr)
+			return nil, err
+		}
+		return &gzipReader{Reader: zr}, nil
+	}
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &gzipReader{Reader: zr}, nil
+}
+
+type zstdReader struct {
+	*zstd.Decoder
+}
+
+// Close 释放解码器
+func (z *zstdReader) Close() error {
+	z.Decoder.Close()
+	return nil
+}
+
+// Writer zstd 压缩写入器
+func (z *zstdCompressor) Writer(w io.Writer) io.WriteCloser {
+	// 选项固定, NewWriter 不会返回错误
+	zw, _ := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	return zw
+}
+
+// Reader zstd 解压读取器
+func (z *zstdCompressor) Reader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdReader{Decoder: zr}, nil
+}
+
+// Writer snappy 压缩写入器, 与 Compress 同为 framing 格式
+func (s snappyCompressor) Writer(w io.Writer) io.WriteCloser {
+	return snappy.NewBufferedWriter(w)
+}
+
+// Reader snappy 解压读取器, 非 framing 格式时按 block 格式读取全部数据后解压
+func (s snappyCompressor) Reader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(len(snappyMagic)); string(head) == snappyMagic {
+		return io.NopCloser(snappy.NewReader(br)), nil
+	}
+	in, err := io.ReadAll(br)
+	if err != nil || len(in) == 0 {
+		return io.NopCloser(bytes.NewReader(nil)), err
+	}
+	out, err := snappy.Decode(nil, in)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(out)), nil
+}
@@ -1,26 +1,31 @@
 package errno
 
+import "net/http"
+
+// Common 公共模块, 预留系统级错误码 10000-10099, 以及表示成功的 0
+var Common = NewModule("common", 0, 10099)
+
 // nolint: golint
 // common error 预定义错误
 var (
-	Success               = NewError(0, "Ok")
-	ErrInternalServer     = NewError(10000, "Internal server error")
-	ErrInvalidParam       = NewError(10001, "Invalid params")
-	ErrUnauthorized       = NewError(10002, "Unauthorized error")
-	ErrNotFound           = NewError(10003, "Not found")
-	ErrUnknown            = NewError(10004, "Unknown")
-	ErrDeadlineExceeded   = NewError(10005, "Deadline exceeded")
-	ErrAccessDenied       = NewError(10006, "Access denied")
-	ErrLimitExceed        = NewError(10007, "Beyond limit")
-	ErrMethodNotAllowed   = NewError(10008, "Method not allowed")
-	ErrSignParam          = NewError(10011, "Invalid sign")
-	ErrValidation         = NewError(10012, "Validation failed")
-	ErrDatabase           = NewError(10013, "Database error")
-	ErrToken              = NewError(10014, "Gen token error")
-	ErrInvalidToken       = NewError(10015, "Invalid token")
-	ErrTokenTimeout       = NewError(10016, "Token timeout")
-	ErrTooManyRequests    = NewError(10017, "Too many request")
-	ErrInvalidTransaction = NewError(10018, "Invalid transaction")
-	ErrEncrypt            = NewError(10019, "Encrypting the user password error")
-	ErrServiceUnavailable = NewError(10020, "Service Unavailable")
+	Success               = Common.New(0, http.StatusOK, "Ok")
+	ErrInternalServer     = Common.New(10000, http.StatusInternalServerError, "Internal server error")
+	ErrInvalidParam       = Common.New(10001, http.StatusBadRequest, "Invalid params")
+	ErrUnauthorized       = Common.New(10002, http.StatusBadRequest, "Unauthorized error")
+	ErrNotFound           = Common.New(10003, http.StatusNotFound, "Not found")
+	ErrUnknown            = Common.New(10004, http.StatusBadRequest, "Unknown")
+	ErrDeadlineExceeded   = Common.New(10005, http.StatusBadRequest, "Deadline exceeded")
+	ErrAccessDenied       = Common.New(10006, http.StatusBadRequest, "Access denied")
+	ErrLimitExceed        = Common.New(10007, http.StatusBadRequest, "Beyond limit")
+	ErrMethodNotAllowed   = Common.New(10008, http.StatusBadRequest, "Method not allowed")
+	ErrSignParam          = Common.New(10011, http.StatusBadRequest, "Invalid sign")
+	ErrValidation         = Common.New(10012, http.StatusBadRequest, "Validation failed")
+	ErrDatabase           = Common.New(10013, http.StatusBadRequest, "Database error")
+	ErrToken              = Common.New(10014, http.StatusUnauthorized, "Gen token error")
+	ErrInvalidToken       = Common.New(10015, http.StatusUnauthorized, "Invalid token")
+	ErrTokenTimeout       = Common.New(10016, http.StatusUnauthorized, "Token timeout")
+	ErrTooManyRequests    = Common.New(10017, http.StatusTooManyRequests, "Too many request")
+	ErrInvalidTransaction = Common.New(10018, http.StatusBadRequest, "Invalid transaction")
+	ErrEncrypt            = Common.New(10019, http.StatusBadRequest, "Encrypting the user password error")
+	ErrServiceUnavailable = Common.New(10020, http.StatusServiceUnavailable, "Service Unavailable")
 )
@@ -1,39 +1,63 @@
 package errno
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
-)
 
-var _toStatus = map[int]int{
-	Success.Code():               http.StatusOK,
-	ErrInternalServer.Code():     http.StatusInternalServerError,
-	ErrNotFound.Code():           http.StatusNotFound,
-	ErrInvalidParam.Code():       http.StatusBadRequest,
-	ErrToken.Code():              http.StatusUnauthorized,
-	ErrInvalidToken.Code():       http.StatusUnauthorized,
-	ErrTokenTimeout.Code():       http.StatusUnauthorized,
-	ErrTooManyRequests.Code():    http.StatusTooManyRequests,
-	ErrServiceUnavailable.Code(): http.StatusServiceUnavailable,
-}
+	"google.golang.org/grpc/status"
+)
 
 // Error 返回错误码和消息的结构体
 type Error struct {
 	code    int
 	msg     string
 	details []string
+	cause   error
+	status  int            // HTTP 状态码, 注册时声明
+	module  string         // 注册的模块
+	meta    map[string]any // 日志元数据
+	stack   stack          // 调用栈, WithStack 时记录
+	grpc    *status.Status // 由非 errno 的 gRPC 状态还原时保留原状态
 }
 
-// NewError 实例化
+// NewError 实例化, 不会注册到注册表, 预定义错误应使用 Module.New 注册
 func NewError(code int, msg string) *Error {
 	return &Error{code: code, msg: msg}
 }
 
 // Error 获取错误信息
 func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("code：%d, msg:：%s, cause: %s", e.Code(), e.Msg(), e.cause)
+	}
 	return fmt.Sprintf("code：%d, msg:：%s", e.Code(), e.Msg())
 }
 
+// Wrap 返回包装了 cause 的副本, 错误码及消息不变
+func (e *Error) Wrap(cause error) *Error {
+	newError := *e
+	newError.cause = cause
+
+	return &newError
+}
+
+// Unwrap 获取 cause
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is 错误码相同即认为是同一个错误, 支持 errors.Is
+func (e *Error) Is(target error) bool {
+	switch t := target.(type) {
+	case *Error:
+		return t.code == e.code
+	case *Err:
+		return t.Code == e.code
+	}
+	return false
+}
+
 // Code 获取code
 func (e *Error) Code() int {
 	return e.code
@@ -60,10 +84,13 @@ func (e *Error) WithDetails(details ...string) *Error {
 	return &newError
 }
 
-// StatusCode 状态码
+// StatusCode HTTP 状态码, 未声明时使用默认注册表中相同错误码的状态码, 均无时返回 400
 func (e *Error) StatusCode() int {
-	if c, ok := _toStatus[e.code]; ok {
-		return c
+	if e.status != 0 {
+		return e.status
+	}
+	if r, ok := Lookup(e.code); ok && r.status != 0 {
+		return r.status
 	}
 
 	return http.StatusBadRequest
@@ -81,18 +108,49 @@ func (e *Err) Error() string {
 	return fmt.Sprintf("Err - code: %d, message: %s, error: %s", e.Code, e.Message, e.Err)
 }
 
-// DecodeErr 对错误进行解码，返回错误code和错误提示
+// Unwrap 获取 Err
+func (e *Err) Unwrap() error {
+	return e.Err
+}
+
+// Is 错误码相同即认为是同一个错误, 支持 errors.Is
+func (e *Err) Is(target error) bool {
+	switch t := target.(type) {
+	case *Error:
+		return t.code == e.Code
+	case *Err:
+		return t.Code == e.Code
+	}
+	return false
+}
+
+// FromError 沿错误链查找 *Error 或 *Err, 找到时返回对应的 *Error 及 true,
+// 否则返回包装了 err 的 ErrInternalServer 及 false, err 为 nil 时返回 nil, true
+func FromError(err error) (*Error, bool) {
+	if err == nil {
+		return nil, true
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	var ee *Err
+	if errors.As(err, &ee) {
+		return NewError(ee.Code, ee.Message).Wrap(ee.Err), true
+	}
+
+	return ErrInternalServer.Wrap(err), false
+}
+
+// DecodeErr 对错误进行解码，返回错误code和错误提示, 支持被包装的错误
 func DecodeErr(err error) (int, string) {
 	if err == nil {
 		return Success.code, Success.msg
 	}
 
-	switch typed := err.(type) {
-	case *Err:
-		return typed.Code, typed.Message
-	case *Error:
-		return typed.code, typed.msg
-	default:
+	if e, ok := FromError(err); ok {
+		return e.code, e.msg
 	}
 
 	return ErrInternalServer.Code(), err.Error()
@@ -0,0 +1,64 @@
+package errno
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	err := ErrDatabase.Wrap(sql.ErrNoRows)
+	assert.Equal(t, "code：10013, msg:：Database error, cause: sql: no rows in result set", err.Error())
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.ErrorIs(t, err, ErrDatabase)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	// Wrap 不修改原错误
+	assert.Nil(t, ErrDatabase.Unwrap())
+
+	// 多层 pkg/errors 包装
+	wrapped := pkgerrors.Wrap(pkgerrors.Wrapf(ErrNotFound.WithDetails("user"), "find user %d", 1), "service")
+	assert.ErrorIs(t, wrapped, ErrNotFound)
+	assert.ErrorIs(t, fmt.Errorf("handler: %w", wrapped), NewError(10003, "other message"))
+
+	e, ok := FromError(wrapped)
+	assert.True(t, ok)
+	assert.Equal(t, 10003, e.Code())
+	assert.Equal(t, []string{"user"}, e.Details())
+}
+
+func TestErr(t *testing.T) {
+	err := fmt.Errorf("layer: %w", &Err{Code: 20101, Message: "user not found", Err: sql.ErrNoRows})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.ErrorIs(t, err, NewError(20101, ""))
+	assert.True(t, errors.Is(NewError(20101, ""), &Err{Code: 20101}))
+
+	e, ok := FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, 20101, e.Code())
+	assert.Equal(t, "user not found", e.Msg())
+	assert.ErrorIs(t, e, sql.ErrNoRows)
+}
+
+func TestDecodeErr(t *testing.T) {
+	code, msg := DecodeErr(nil)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "Ok", msg)
+
+	code, msg = DecodeErr(pkgerrors.Wrapf(ErrInvalidParam, "bind"))
+	assert.Equal(t, 10001, code)
+	assert.Equal(t, "Invalid params", msg)
+
+	raw := errors.New("raw")
+	code, msg = DecodeErr(raw)
+	assert.Equal(t, 10000, code)
+	assert.Equal(t, "raw", msg)
+
+	e, ok := FromError(raw)
+	assert.False(t, ok)
+	assert.ErrorIs(t, e, ErrInternalServer)
+	assert.ErrorIs(t, e, raw)
+}
@@ -0,0 +1,117 @@
+package errno
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WithStack 返回记录了调用位置调用栈的副本, %+v 格式化及日志输出时包含调用栈
+func (e *Error) WithStack() *Error {
+	newError := *e
+	newError.stack = callers(1)
+
+	return &newError
+}
+
+// WithMeta 返回附加了 key/value 元数据的副本, 用于日志输出
+func (e *Error) WithMeta(kv ...any) *Error {
+	newError := *e
+	newError.meta = make(map[string]any, len(e.meta)+len(kv)/2)
+	for k, v := range e.meta {
+		newError.meta[k] = v
+	}
+	for i := 0; i < len(kv); i += 2 {
+		key := fmt.Sprint(kv[i])
+		if i+1 < len(kv) {
+			newError.meta[key] = kv[i+1]
+		} else {
+			newError.meta[key] = nil
+		}
+	}
+
+	return &newError
+}
+
+// Meta 获取元数据
+func (e *Error) Meta() map[string]any {
+	return e.meta
+}
+
+// Stack 获取调用栈, 未记录时为空
+func (e *Error) Stack() string {
+	if len(e.stack) == 0 {
+		return ""
+	}
+	return e.stack.String()
+}
+
+// Format implements fmt.Formatter, %+v 输出 details, 元数据, cause 及调用栈
+func (e *Error) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = io.WriteString(s, e.Error())
+			if len(e.details) != 0 {
+				fmt.Fprintf(s, "\ndetails: %v", e.details)
+			}
+			for _, k := range e.metaKeys() {
+				fmt.Fprintf(s, "\n%s: %v", k, e.meta[k])
+			}
+			if len(e.stack) != 0 {
+				e.stack.Format(s)
+			}
+			if e.cause != nil {
+				fmt.Fprintf(s, "\ncause: %+v", e.cause)
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler, 输出 code, msg, details, 元数据, cause 及调用栈
+func (e *Error) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("code", e.code)
+	enc.AddString("msg", e.msg)
+	if len(e.details) != 0 {
+		_ = enc.AddArray("details", zapcore.ArrayMarshalerFunc(func(ae zapcore.ArrayEncoder) error {
+			for _, d := range e.details {
+				ae.AppendString(d)
+			}
+			return nil
+		}))
+	}
+	if len(e.meta) != 0 {
+		_ = enc.AddObject("meta", zapcore.ObjectMarshalerFunc(func(oe zapcore.ObjectEncoder) error {
+			for _, k := range e.metaKeys() {
+				if err := oe.AddReflected(k, e.meta[k]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}
+	if e.cause != nil {
+		enc.AddString("cause", e.cause.Error())
+	}
+	if len(e.stack) != 0 {
+		enc.AddString("stack", e.stack.String())
+	}
+	return nil
+}
+
+func (e *Error) metaKeys() []string {
+	keys := make([]string, 0, len(e.meta))
+	for k := range e.meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
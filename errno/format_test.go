@@ -0,0 +1,56 @@
+package errno
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestFormat(t *testing.T) {
+	err := ErrDatabase.Wrap(pkgerrors.New("deadlock")).WithDetails("orders").
+		WithMeta("table", "orders", "rows", 3).WithStack()
+
+	assert.Equal(t, err.Error(), fmt.Sprintf("%v", err))
+	assert.Equal(t, err.Error(), fmt.Sprintf("%s", err))
+	assert.Equal(t, fmt.Sprintf("%q", err.Error()), fmt.Sprintf("%q", err))
+
+	verbose := fmt.Sprintf("%+v", err)
+	assert.True(t, strings.HasPrefix(verbose, "code：10013, msg:：Database error, cause: deadlock\n"+
+		"details: [orders]\nrows: 3\ntable: orders\n"), verbose)
+	// 调用栈从 WithStack 的调用位置开始, cause 的调用栈同样输出
+	assert.Contains(t, verbose, "errno.TestFormat\n\t")
+	assert.Contains(t, verbose, "format_test.go:")
+	assert.Contains(t, verbose, "cause: deadlock\n")
+	assert.Equal(t, 2, strings.Count(verbose, "errno.TestFormat\n"))
+
+	assert.Equal(t, map[string]any{"table": "orders", "rows": 3}, err.Meta())
+	assert.Nil(t, ErrDatabase.Meta())
+	assert.Empty(t, ErrDatabase.Stack())
+	assert.True(t, strings.HasPrefix(err.Stack(), "github.com/binbinly/pkg/errno.TestFormat\n"))
+
+	// WithMeta 不修改原错误
+	e2 := err.WithMeta("rows", 4, "odd")
+	assert.Equal(t, 3, err.Meta()["rows"])
+	assert.Equal(t, map[string]any{"table": "orders", "rows": 4, "odd": nil}, e2.Meta())
+}
+
+func TestMarshalLogObject(t *testing.T) {
+	err := ErrNotFound.WithDetails("user").WithMeta("uid", 42).Wrap(pkgerrors.New("no rows"))
+	enc := zapcore.NewMapObjectEncoder()
+	assert.NoError(t, err.MarshalLogObject(enc))
+	assert.Equal(t, map[string]any{
+		"code":    10003,
+		"msg":     "Not found",
+		"details": []any{"user"},
+		"meta":    map[string]any{"uid": 42},
+		"cause":   "no rows",
+	}, enc.Fields)
+
+	enc = zapcore.NewMapObjectEncoder()
+	assert.NoError(t, ErrNotFound.WithStack().MarshalLogObject(enc))
+	assert.Contains(t, enc.Fields["stack"], "errno.TestMarshalLogObject")
+}
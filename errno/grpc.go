@@ -0,0 +1,124 @@
+package errno
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain gRPC ErrorInfo 的 domain, 用于识别 errno 错误
+const ErrorDomain = "errno"
+
+// GRPCCode 按 HTTP 状态码映射的 gRPC 状态码
+func (e *Error) GRPCCode() codes.Code {
+	switch s := e.StatusCode(); s {
+	case http.StatusOK:
+		return codes.OK
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case 499:
+		return codes.Canceled
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		if s >= http.StatusInternalServerError {
+			return codes.Internal
+		}
+		return codes.Unknown
+	}
+}
+
+// GRPCStatus 转换为 gRPC 状态, 业务错误码及 details 保存在 errdetails.ErrorInfo 中,
+// 实现了 status.FromError 使用的接口; 由非 errno 的 gRPC 状态还原的错误返回原状态, 保留原状态码
+func (e *Error) GRPCStatus() *status.Status {
+	if e.grpc != nil {
+		return e.grpc
+	}
+	md := map[string]string{"code": strconv.Itoa(e.code)}
+	if len(e.details) != 0 {
+		b, _ := json.Marshal(e.details)
+		md["details"] = string(b)
+	}
+	s := status.New(e.GRPCCode(), e.msg)
+	if ds, err := s.WithDetails(&errdetails.ErrorInfo{
+		Reason:   strconv.Itoa(e.code),
+		Domain:   ErrorDomain,
+		Metadata: md,
+	}); err == nil {
+		return ds
+	}
+	return s
+}
+
+// FromGRPCStatus 由 gRPC 状态还原错误, 状态中没有业务错误码时按 gRPC 状态码映射为预定义错误,
+// 此时 gRPC 状态错误作为 cause, GRPCStatus 返回原状态, s 为 nil 或 OK 时返回 nil
+func FromGRPCStatus(s *status.Status) *Error {
+	if s == nil || s.Code() == codes.OK {
+		return nil
+	}
+	for _, d := range s.Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if !ok || info.GetDomain() != ErrorDomain {
+			continue
+		}
+		code, err := strconv.Atoi(info.GetMetadata()["code"])
+		if err != nil {
+			continue
+		}
+		e := NewError(code, s.Message())
+		if v := info.GetMetadata()["details"]; v != "" {
+			var details []string
+			if json.Unmarshal([]byte(v), &details) == nil {
+				e.details = details
+			}
+		}
+		return e
+	}
+
+	var e *Error
+	switch s.Code() {
+	case codes.InvalidArgument, codes.OutOfRange:
+		e = ErrInvalidParam
+	case codes.Unauthenticated:
+		e = ErrUnauthorized
+	case codes.PermissionDenied:
+		e = ErrAccessDenied
+	case codes.NotFound:
+		e = ErrNotFound
+	case codes.ResourceExhausted:
+		e = ErrTooManyRequests
+	case codes.DeadlineExceeded:
+		e = ErrDeadlineExceeded
+	case codes.Unimplemented:
+		e = ErrMethodNotAllowed
+	case codes.Unavailable:
+		e = ErrServiceUnavailable
+	case codes.Unknown:
+		e = ErrUnknown
+	default:
+		e = ErrInternalServer
+	}
+	e = e.Wrap(s.Err())
+	e.grpc = s
+	return e
+}
@@ -0,0 +1,51 @@
+package errno
+
+import (
+	"errors"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCStatus(t *testing.T) {
+	s := ErrNotFound.WithDetails("user", "42").GRPCStatus()
+	assert.Equal(t, codes.NotFound, s.Code())
+	assert.Equal(t, "Not found", s.Message())
+
+	e := FromGRPCStatus(s)
+	assert.Equal(t, 10003, e.Code())
+	assert.Equal(t, "Not found", e.Msg())
+	assert.Equal(t, []string{"user", "42"}, e.Details())
+	assert.Equal(t, 404, e.StatusCode())
+	assert.ErrorIs(t, e, ErrNotFound)
+
+	// 经过 pkg/errors 包装后 status.FromError 仍可识别
+	s, ok := status.FromError(pkgerrors.Wrap(ErrTooManyRequests, "call"))
+	assert.True(t, ok)
+	assert.Equal(t, codes.ResourceExhausted, s.Code())
+
+	assert.Equal(t, codes.Internal, ErrInternalServer.GRPCCode())
+	assert.Equal(t, codes.Unauthenticated, ErrInvalidToken.GRPCCode())
+	assert.Equal(t, codes.InvalidArgument, NewError(99999, "unknown").GRPCCode())
+	assert.Equal(t, codes.OK, Success.GRPCCode())
+}
+
+func TestFromGRPCStatus(t *testing.T) {
+	assert.Nil(t, FromGRPCStatus(nil))
+	assert.Nil(t, FromGRPCStatus(status.New(codes.OK, "")))
+
+	// 没有业务错误码时按 gRPC 状态码映射
+	s := status.New(codes.PermissionDenied, "denied")
+	e := FromGRPCStatus(s)
+	assert.ErrorIs(t, e, ErrAccessDenied)
+	var se interface{ GRPCStatus() *status.Status }
+	assert.True(t, errors.As(e.Unwrap(), &se))
+	assert.Equal(t, "denied", se.GRPCStatus().Message())
+
+	assert.ErrorIs(t, FromGRPCStatus(status.New(codes.DataLoss, "")), ErrInternalServer)
+	assert.ErrorIs(t, FromGRPCStatus(status.New(codes.Unavailable, "")), ErrServiceUnavailable)
+	assert.Equal(t, codes.Aborted, status.Code(FromGRPCStatus(status.New(codes.Aborted, ""))))
+}
@@ -0,0 +1,196 @@
+package errno
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// DefaultI18n 默认消息目录, 已加载预定义错误的中英文消息
+var DefaultI18n = newDefaultI18n()
+
+func newDefaultI18n() *I18n {
+	i := NewI18n()
+	if err := i.LoadFS(locales, "locales"); err != nil {
+		panic(err)
+	}
+	return i
+}
+
+// I18n 按错误码及语言保存的消息目录, 消息为 text/template 模板, 可并发使用
+//
+// 模板数据为 Code, Msg 及 Details, 并可使用 arg 函数按下标获取 details, 如:
+//
+//	"10001": "参数错误{{if arg 0}}: {{arg 0}}{{end}}"
+type I18n struct {
+	mu       sync.RWMutex
+	tags     []language.Tag // tags[0] 为 und, 未匹配时使用错误的原始消息
+	messages []map[int]*template.Template
+	matcher  language.Matcher
+}
+
+// NewI18n 实例化消息目录
+func NewI18n() *I18n {
+	i := &I18n{
+		tags:     []language.Tag{language.Und},
+		messages: []map[int]*template.Template{nil},
+	}
+	i.matcher = language.NewMatcher(i.tags)
+	return i
+}
+
+// Add 添加 lang 语言的消息, 已存在的错误码会被覆盖
+func (i *I18n) Add(lang string, messages map[int]string) error {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return fmt.Errorf("errno: invalid language %q: %w", lang, err)
+	}
+	tpls := make(map[int]*template.Template, len(messages))
+	for code, msg := range messages {
+		tpl, err := template.New(strconv.Itoa(code)).Funcs(template.FuncMap{"arg": noArg}).Parse(msg)
+		if err != nil {
+			return fmt.Errorf("errno: parse %s message of code %d: %w", lang, code, err)
+		}
+		tpls[code] = tpl
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for idx, t := range i.tags {
+		if idx > 0 && t == tag {
+			for code, tpl := range tpls {
+				i.messages[idx][code] = tpl
+			}
+			return nil
+		}
+	}
+	i.tags = append(i.tags, tag)
+	i.messages = append(i.messages, tpls)
+	i.matcher = language.NewMatcher(i.tags)
+	return nil
+}
+
+// LoadFS 加载 dir 目录下的 json 及 yaml 消息文件, 文件名为语言, 如 zh-CN.json, en.yaml
+// 文件内容为错误码到消息的映射
+func (i *I18n) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		ext := path.Ext(name)
+		if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		messages := make(map[int]string)
+		if ext == ".json" {
+			err = json.Unmarshal(data, &messages)
+		} else {
+			err = yaml.Unmarshal(data, &messages)
+		}
+		if err != nil {
+			return fmt.Errorf("errno: parse %s: %w", name, err)
+		}
+		if err = i.Add(strings.TrimSuffix(name, ext), messages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Localize 按 lang 本地化错误消息, lang 可以是语言或 Accept-Language 头, 如 zh-CN,zh;q=0.9,en;q=0.8
+// 非 errno 错误按 ErrInternalServer 处理, 没有匹配的消息时保留原始消息, err 为 nil 时返回 nil
+func (i *I18n) Localize(err error, lang string) *Error {
+	e, _ := FromError(err)
+	if e == nil {
+		return nil
+	}
+	tpl := i.template(e.code, lang)
+	if tpl == nil {
+		return e
+	}
+
+	var b strings.Builder
+	data := struct {
+		Code    int
+		Msg     string
+		Details []string
+	}{e.code, e.msg, e.details}
+	details := e.details
+	if err = template.Must(tpl.Clone()).Funcs(template.FuncMap{"arg": func(n int) string {
+		if n < 0 || n >= len(details) {
+			return ""
+		}
+		return details[n]
+	}}).Execute(&b, data); err != nil {
+		return e
+	}
+
+	newError := *e
+	newError.msg = b.String()
+	return &newError
+}
+
+// template 按语言匹配错误码的消息模板
+func (i *I18n) template(code int, lang string) *template.Template {
+	tags, _, err := language.ParseAcceptLanguage(lang)
+	if err != nil || len(tags) == 0 {
+		return nil
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	_, idx, conf := i.matcher.Match(tags...)
+	if idx == 0 || conf == language.No {
+		return nil
+	}
+	return i.messages[idx][code]
+}
+
+func noArg(int) string {
+	return ""
+}
+
+type languageKey struct{}
+
+// WithLanguage 在 ctx 中保存语言, 可以是 Accept-Language 头
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+// Language 获取 ctx 中的语言
+func Language(ctx context.Context) string {
+	lang, _ := ctx.Value(languageKey{}).(string)
+	return lang
+}
+
+// Localize 使用默认消息目录本地化错误消息
+func Localize(err error, lang string) *Error {
+	return DefaultI18n.Localize(err, lang)
+}
+
+// LocalizeContext 使用默认消息目录及 ctx 中的语言本地化错误消息
+func LocalizeContext(ctx context.Context, err error) *Error {
+	return DefaultI18n.Localize(err, Language(ctx))
+}
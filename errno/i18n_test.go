@@ -0,0 +1,65 @@
+package errno
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalize(t *testing.T) {
+	err := pkgerrors.Wrap(ErrNotFound, "find user")
+	assert.Equal(t, "资源不存在", Localize(err, "zh-CN,zh;q=0.9,en;q=0.8").Msg())
+	assert.Equal(t, "资源不存在", Localize(err, "zh-TW").Msg())
+	assert.Equal(t, "Not found", Localize(err, "en-US").Msg())
+	// 未匹配的语言保留原始消息
+	assert.Equal(t, "Not found", Localize(err, "fr").Msg())
+	assert.Equal(t, "Not found", Localize(err, "").Msg())
+	assert.Equal(t, "Not found", ErrNotFound.Msg())
+
+	e := Localize(ErrInvalidParam.WithDetails("name"), "zh")
+	assert.Equal(t, "参数错误: name", e.Msg())
+	assert.Equal(t, 10001, e.Code())
+	assert.Equal(t, []string{"name"}, e.Details())
+	assert.Equal(t, "参数错误", Localize(ErrInvalidParam, "zh").Msg())
+
+	// 非 errno 错误按内部错误处理
+	raw := errors.New("dial tcp: connection refused")
+	e = Localize(raw, "zh")
+	assert.Equal(t, "服务器内部错误", e.Msg())
+	assert.ErrorIs(t, e, raw)
+	assert.Nil(t, Localize(nil, "zh"))
+
+	ctx := WithLanguage(context.Background(), "zh-CN")
+	assert.Equal(t, "zh-CN", Language(ctx))
+	assert.Equal(t, "请求过于频繁", LocalizeContext(ctx, ErrTooManyRequests).Msg())
+	assert.Equal(t, "", Language(context.Background()))
+}
+
+func TestI18nLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"i18n/zh-CN.yaml": {Data: []byte("20101: \"用户 {{arg 0}} 不存在\"\n20102: 用户已存在\n")},
+		"i18n/en.json":    {Data: []byte(`{"20101": "User {{arg 0}} not found ({{.Code}})"}`)},
+		"i18n/README.md":  {Data: []byte("ignored")},
+	}
+	i := NewI18n()
+	assert.NoError(t, i.LoadFS(fsys, "i18n"))
+
+	errUser := NewError(20101, "User not found").WithDetails("42")
+	assert.Equal(t, "用户 42 不存在", i.Localize(errUser, "zh").Msg())
+	assert.Equal(t, "User 42 not found (20101)", i.Localize(errUser, "en").Msg())
+	assert.Equal(t, "用户已存在", i.Localize(NewError(20102, "User exists"), "zh-CN").Msg())
+	// 未配置的错误码保留原始消息
+	assert.Equal(t, "Not found", i.Localize(ErrNotFound, "zh").Msg())
+
+	// 覆盖已有消息
+	assert.NoError(t, i.Add("zh-CN", map[int]string{20102: "用户名已被占用"}))
+	assert.Equal(t, "用户名已被占用", i.Localize(NewError(20102, "User exists"), "zh").Msg())
+
+	assert.Error(t, i.Add("zh", map[int]string{1: "{{"}))
+	assert.Error(t, i.Add("not a language!", map[int]string{1: "x"}))
+	assert.Error(t, i.LoadFS(fstest.MapFS{"i18n/en.json": {Data: []byte("{")}}, "i18n"))
+}
@@ -0,0 +1,152 @@
+package errno
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultRegistry 默认错误码注册表, 预定义错误及 NewModule 均注册在此
+var DefaultRegistry = NewRegistry()
+
+// Entry 错误码目录条目
+type Entry struct {
+	Code    int    `json:"code"`
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Module  string `json:"module"`
+}
+
+// Registry 错误码注册表, 每个模块预留一段错误码, 重复注册时 panic, 可并发使用
+type Registry struct {
+	mu      sync.RWMutex
+	modules []*Module
+	errors  map[int]*Error
+}
+
+// NewRegistry 实例化错误码注册表
+func NewRegistry() *Registry {
+	return &Registry{errors: make(map[int]*Error)}
+}
+
+// Module 模块, 持有 [min, max] 区间的错误码
+type Module struct {
+	registry *Registry
+	name     string
+	min      int
+	max      int
+}
+
+// NewModule 在 r 中预留 [min, max] 区间的错误码, 名称重复或区间重叠时 panic
+func (r *Registry) NewModule(name string, min, max int) *Module {
+	if name == "" {
+		panic("errno: module name is empty")
+	}
+	if min < 0 || min > max {
+		panic(fmt.Sprintf("errno: module %s has invalid code range [%d, %d]", name, min, max))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.modules {
+		if m.name == name {
+			panic(fmt.Sprintf("errno: module %s already registered", name))
+		}
+		if min <= m.max && m.min <= max {
+			panic(fmt.Sprintf("errno: module %s code range [%d, %d] overlaps module %s [%d, %d]",
+				name, min, max, m.name, m.min, m.max))
+		}
+	}
+	m := &Module{registry: r, name: name, min: min, max: max}
+	r.modules = append(r.modules, m)
+	return m
+}
+
+// Lookup 按错误码查找已注册的错误
+func (r *Registry) Lookup(code int) (*Error, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.errors[code]
+	return e, ok
+}
+
+// Catalogue 按错误码排序的全部错误
+func (r *Registry) Catalogue() []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]Entry, 0, len(r.errors))
+	for _, e := range r.errors {
+		entries = append(entries, Entry{Code: e.code, Status: e.status, Message: e.msg, Module: e.module})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Code < entries[j].Code
+	})
+	return entries
+}
+
+// ExportJSON 导出 json 格式的错误码目录
+func (r *Registry) ExportJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.Catalogue())
+}
+
+// ExportMarkdown 导出 markdown 表格格式的错误码目录, 供接口文档使用
+func (r *Registry) ExportMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("| Code | HTTP Status | Module | Message |\n")
+	b.WriteString("| :--- | :--- | :--- | :--- |\n")
+	for _, e := range r.Catalogue() {
+		fmt.Fprintf(&b, "| %d | %d %s | %s | %s |\n",
+			e.Code, e.Status, http.StatusText(e.Status), e.Module, strings.ReplaceAll(e.Message, "|", `\|`))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Name 模块名称
+func (m *Module) Name() string {
+	return m.name
+}
+
+// Range 模块错误码区间
+func (m *Module) Range() (min, max int) {
+	return m.min, m.max
+}
+
+// New 注册错误, status 为对应的 HTTP 状态码, 错误码超出模块区间或已注册时 panic
+func (m *Module) New(code, status int, msg string) *Error {
+	if code < m.min || code > m.max {
+		panic(fmt.Sprintf("errno: code %d out of module %s range [%d, %d]", code, m.name, m.min, m.max))
+	}
+
+	r := m.registry
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.errors[code]; ok {
+		panic(fmt.Sprintf("errno: code %d already registered by module %s: %s", code, e.module, e.msg))
+	}
+	e := &Error{code: code, msg: msg, status: status, module: m.name}
+	r.errors[code] = e
+	return e
+}
+
+// NewModule 在默认注册表中预留 [min, max] 区间的错误码
+func NewModule(name string, min, max int) *Module {
+	return DefaultRegistry.NewModule(name, min, max)
+}
+
+// Lookup 在默认注册表中按错误码查找错误
+func Lookup(code int) (*Error, bool) {
+	return DefaultRegistry.Lookup(code)
+}
+
+// Catalogue 默认注册表的错误码目录
+func Catalogue() []Entry {
+	return DefaultRegistry.Catalogue()
+}
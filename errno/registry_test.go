@@ -0,0 +1,62 @@
+package errno
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	user := r.NewModule("user", 20100, 20199)
+	errUserNotFound := user.New(20101, http.StatusNotFound, "User not found")
+	user.New(20102, http.StatusConflict, "User exists")
+
+	assert.Equal(t, http.StatusNotFound, errUserNotFound.StatusCode())
+	assert.Equal(t, http.StatusNotFound, errUserNotFound.WithDetails("uid").Wrap(ErrDatabase).StatusCode())
+	e, ok := r.Lookup(20101)
+	assert.True(t, ok)
+	assert.Same(t, errUserNotFound, e)
+	_, ok = r.Lookup(20103)
+	assert.False(t, ok)
+
+	assert.PanicsWithValue(t, "errno: code 20101 already registered by module user: User not found", func() {
+		user.New(20101, http.StatusNotFound, "dup")
+	})
+	assert.Panics(t, func() { user.New(20200, http.StatusBadRequest, "out of range") })
+	assert.Panics(t, func() { r.NewModule("order", 20150, 20299) })
+	assert.Panics(t, func() { r.NewModule("user", 20300, 20399) })
+	assert.Panics(t, func() { r.NewModule("bad", 20399, 20300) })
+	assert.NotPanics(t, func() { r.NewModule("order", 20200, 20299) })
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.ExportJSON(&buf))
+	var entries []Entry
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entries))
+	assert.Equal(t, []Entry{
+		{Code: 20101, Status: http.StatusNotFound, Message: "User not found", Module: "user"},
+		{Code: 20102, Status: http.StatusConflict, Message: "User exists", Module: "user"},
+	}, entries)
+
+	buf.Reset()
+	assert.NoError(t, r.ExportMarkdown(&buf))
+	assert.Equal(t, "| Code | HTTP Status | Module | Message |\n"+
+		"| :--- | :--- | :--- | :--- |\n"+
+		"| 20101 | 404 Not Found | user | User not found |\n"+
+		"| 20102 | 409 Conflict | user | User exists |\n", buf.String())
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	e, ok := Lookup(10003)
+	assert.True(t, ok)
+	assert.Same(t, ErrNotFound, e)
+	assert.Len(t, Catalogue(), 20)
+	assert.Panics(t, func() { NewModule("system", 10050, 10150) })
+
+	// 未注册的错误使用默认注册表中相同错误码的状态码
+	assert.Equal(t, http.StatusTooManyRequests, NewError(10017, "limited").StatusCode())
+	assert.Equal(t, http.StatusBadRequest, NewError(99999, "unknown").StatusCode())
+}
@@ -0,0 +1,35 @@
+package errno
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+)
+
+// stack 调用栈
+type stack []uintptr
+
+func callers(skip int) stack {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip+2, pcs)
+	return pcs[:n]
+}
+
+// Format 每个调用帧输出为 "函数\n\t文件:行号"
+func (s stack) Format(w io.Writer) {
+	frames := runtime.CallersFrames(s)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(w, "\n%s\n\t%s:%d", f.Function, f.File, f.Line)
+		if !more {
+			return
+		}
+	}
+}
+
+func (s stack) String() string {
+	var b strings.Builder
+	s.Format(&b)
+	return strings.TrimPrefix(b.String(), "\n")
+}
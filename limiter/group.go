@@ -0,0 +1,119 @@
+package limiter
+
+import (
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Group 按 key 管理限流器, key 通常为 host+path (客户端) 或请求路径 (服务端)
+//
+// 规则按添加顺序匹配, pattern 使用 path.Match 语法, 同时匹配 key 的上级路径,
+// 如 "api.example.com" 匹配 "api.example.com/v1/users", "*.example.com/v1/*" 匹配 "a.example.com/v1/users/1",
+// 同一规则命中的所有 key 共享一个限流器; 未命中规则时按 key 的第一段 (客户端为 host) 创建默认限流器
+type Group struct {
+	mu       sync.RWMutex
+	rules    []*rule
+	def      []Option
+	limiters map[string]*Limiter
+}
+
+type rule struct {
+	pattern string
+	limiter *Limiter
+}
+
+// NewGroup 创建限流器组, def 为未命中规则时的默认配置, 为空时不限流
+func NewGroup(def ...Option) *Group {
+	return &Group{
+		def:      def,
+		limiters: make(map[string]*Limiter),
+	}
+}
+
+// Add 添加规则, 命中 pattern 的请求共享一个限流器
+func (g *Group) Add(pattern string, opts ...Option) *Group {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.rules = append(g.rules, &rule{pattern: pattern, limiter: New(opts...)})
+	return g
+}
+
+// Get 获取 key 对应的限流器, 没有限制时返回 nil
+func (g *Group) Get(key string) *Limiter {
+	g.mu.RLock()
+	for _, r := range g.rules {
+		if match(r.pattern, key) {
+			g.mu.RUnlock()
+			return r.limiter
+		}
+	}
+	if len(g.def) == 0 {
+		g.mu.RUnlock()
+		return nil
+	}
+	root, _, _ := strings.Cut(key, "/")
+	l, ok := g.limiters[root]
+	g.mu.RUnlock()
+	if ok {
+		return l
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if l, ok = g.limiters[root]; !ok {
+		l = New(g.def...)
+		g.limiters[root] = l
+	}
+	return l
+}
+
+// States 获取所有限流器状态, 规则以 pattern 为 key
+func (g *Group) States() map[string]State {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	states := make(map[string]State, len(g.rules)+len(g.limiters))
+	for _, r := range g.rules {
+		states[r.pattern] = r.limiter.State()
+	}
+	for k, l := range g.limiters {
+		states[k] = l.State()
+	}
+	return states
+}
+
+// Middleware 服务端限流中间件, 按请求路径限流, 超过限制时返回 429
+func (g *Group) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := g.Get(r.URL.Path)
+		if l == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		release, err := l.Acquire(r.Context())
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		defer release()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// match 匹配 key 及其上级路径
+func match(pattern, key string) bool {
+	for {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+		i := strings.LastIndexByte(key, '/')
+		if i < 0 {
+			return false
+		}
+		key = key[:i]
+	}
+}
@@ -0,0 +1,155 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrLimited 超过限流
+var ErrLimited = errors.New("limiter: rate limit exceeded")
+
+// Option configures the limiter
+type Option func(*options)
+
+type options struct {
+	rate        float64
+	burst       int
+	maxInFlight int
+	failFast    bool
+}
+
+// WithRate specifies the token bucket rate per second and burst, rate <= 0 means no rate limit.
+func WithRate(rate float64, burst int) Option {
+	return func(o *options) {
+		o.rate = rate
+		o.burst = burst
+	}
+}
+
+// WithMaxInFlight specifies the max concurrent requests, n <= 0 means no limit.
+func WithMaxInFlight(n int) Option {
+	return func(o *options) {
+		o.maxInFlight = n
+	}
+}
+
+// WithFailFast returns ErrLimited immediately instead of waiting when the limit is exceeded.
+func WithFailFast() Option {
+	return func(o *options) {
+		o.failFast = true
+	}
+}
+
+// State 限流器状态, 用于调试
+type State struct {
+	Rate        float64 `json:"rate"`
+	Burst       int     `json:"burst"`
+	Tokens      float64 `json:"tokens"`
+	MaxInFlight int     `json:"max_in_flight"`
+	InFlight    int     `json:"in_flight"`
+	Waiting     int64   `json:"waiting"`
+	Allowed     uint64  `json:"allowed"`
+	Rejected    uint64  `json:"rejected"`
+}
+
+// Limiter 组合令牌桶限速与最大并发数限制
+type Limiter struct {
+	opts     options
+	bucket   *TokenBucket
+	sem      *Semaphore
+	waiting  atomic.Int64
+	allowed  atomic.Uint64
+	rejected atomic.Uint64
+}
+
+// New 创建限流器
+func New(opts ...Option) *Limiter {
+	var o options
+	for _, f := range opts {
+		f(&o)
+	}
+
+	l := &Limiter{opts: o}
+	if o.rate > 0 {
+		l.bucket = NewTokenBucket(o.rate, o.burst)
+	}
+	if o.maxInFlight > 0 {
+		l.sem = NewSemaphore(o.maxInFlight)
+	}
+	return l
+}
+
+// Acquire 获取一次许可, 成功时返回的 release 必须在请求结束后调用以释放并发数
+// 超过限制时等待直到 ctx 结束, WithFailFast 时直接返回 ErrLimited
+func (l *Limiter) Acquire(ctx context.Context) (release func(), err error) {
+	if err = l.acquire(ctx); err != nil {
+		l.rejected.Add(1)
+		return nil, err
+	}
+	l.allowed.Add(1)
+
+	if l.sem == nil {
+		return func() {}, nil
+	}
+	var once atomic.Bool
+	return func() {
+		if once.CompareAndSwap(false, true) {
+			l.sem.Release()
+		}
+	}, nil
+}
+
+func (l *Limiter) acquire(ctx context.Context) error {
+	if l.opts.failFast {
+		if l.sem != nil && !l.sem.TryAcquire() {
+			return ErrLimited
+		}
+		if l.bucket != nil && !l.bucket.Allow() {
+			l.release()
+			return ErrLimited
+		}
+		return nil
+	}
+
+	l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+
+	if l.sem != nil {
+		if err := l.sem.Acquire(ctx); err != nil {
+			return err
+		}
+	}
+	if l.bucket != nil {
+		if err := l.bucket.Wait(ctx); err != nil {
+			l.release()
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Limiter) release() {
+	if l.sem != nil {
+		l.sem.Release()
+	}
+}
+
+// State 获取当前状态
+func (l *Limiter) State() State {
+	s := State{
+		Rate:        l.opts.rate,
+		Burst:       l.opts.burst,
+		MaxInFlight: l.opts.maxInFlight,
+		Waiting:     l.waiting.Load(),
+		Allowed:     l.allowed.Load(),
+		Rejected:    l.rejected.Load(),
+	}
+	if l.bucket != nil {
+		s.Tokens = l.bucket.Tokens()
+	}
+	if l.sem != nil {
+		s.InFlight = l.sem.InFlight()
+	}
+	return s
+}
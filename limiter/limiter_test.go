@@ -0,0 +1,129 @@
+package limiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := NewTokenBucket(100, 2)
+	assert.True(t, b.Allow())
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+
+	start := time.Now()
+	assert.NoError(t, b.Wait(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 5*time.Millisecond)
+
+	// 等待时间超过截止时间时直接返回
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.NoError(t, b.Wait(context.Background()))
+	assert.ErrorIs(t, b.Wait(ctx), ErrLimited)
+}
+
+func TestLimiterFailFast(t *testing.T) {
+	l := New(WithRate(1, 1), WithMaxInFlight(1), WithFailFast())
+
+	release, err := l.Acquire(context.Background())
+	assert.NoError(t, err)
+	_, err = l.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrLimited)
+	release()
+	release()
+
+	// 并发数已释放, 但令牌已用完
+	_, err = l.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrLimited)
+
+	s := l.State()
+	assert.Equal(t, uint64(1), s.Allowed)
+	assert.Equal(t, uint64(2), s.Rejected)
+	assert.Equal(t, 0, s.InFlight)
+	assert.Equal(t, 1, s.MaxInFlight)
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := New(WithMaxInFlight(2))
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		cur     int
+		maxSeen int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := l.Acquire(context.Background())
+			assert.NoError(t, err)
+			defer release()
+
+			mu.Lock()
+			cur++
+			if cur > maxSeen {
+				maxSeen = cur
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			cur--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, maxSeen)
+
+	release, err := l.Acquire(context.Background())
+	assert.NoError(t, err)
+	defer release()
+	_, _ = l.Acquire(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestGroup(t *testing.T) {
+	g := NewGroup(WithRate(10, 1)).
+		Add("*.example.com/v1/orders", WithMaxInFlight(1)).
+		Add("api.example.com", WithRate(1, 5))
+
+	orders := g.Get("a.example.com/v1/orders/1")
+	assert.Same(t, orders, g.Get("b.example.com/v1/orders"))
+	assert.Equal(t, 1, orders.State().MaxInFlight)
+
+	api := g.Get("api.example.com/v1/users")
+	assert.Equal(t, 5, api.State().Burst)
+
+	// 未命中规则时按 host 创建默认限流器
+	other := g.Get("other.com/a")
+	assert.Same(t, other, g.Get("other.com/b"))
+	assert.NotSame(t, other, g.Get("another.com/a"))
+
+	states := g.States()
+	assert.Len(t, states, 4)
+	assert.Equal(t, float64(10), states["other.com"].Rate)
+
+	assert.Nil(t, NewGroup().Get("other.com"))
+}
+
+func TestMiddleware(t *testing.T) {
+	g := NewGroup().Add("/limited", WithRate(1, 1), WithFailFast())
+	h := g.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := make([]int, 0, 3)
+	for _, p := range []string{"/limited", "/limited", "/free"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, p, nil))
+		codes = append(codes, w.Code)
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK}, codes)
+}
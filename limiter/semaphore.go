@@ -0,0 +1,48 @@
+package limiter
+
+import "context"
+
+// Semaphore 限制最大并发数
+type Semaphore struct {
+	ch chan struct{}
+}
+
+// NewSemaphore 创建信号量, n 为最大并发数
+func NewSemaphore(n int) *Semaphore {
+	return &Semaphore{ch: make(chan struct{}, n)}
+}
+
+// TryAcquire 获取许可, 已达上限时返回 false
+func (s *Semaphore) TryAcquire() bool {
+	select {
+	case s.ch <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Acquire 获取许可, 已达上限时等待直到 ctx 结束
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	select {
+	case s.ch <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release 释放许可
+func (s *Semaphore) Release() {
+	<-s.ch
+}
+
+// InFlight 当前并发数
+func (s *Semaphore) InFlight() int {
+	return len(s.ch)
+}
+
+// Cap 最大并发数
+func (s *Semaphore) Cap() int {
+	return cap(s.ch)
+}
@@ -57,3 +57,184 @@ func TestLockWithTimeout(t *testing.T) {
 		//assert.False(t, ok)
 	})
 }
+
+func TestRetryStrategy(t *testing.T) {
+	assert.Equal(t, time.Duration(-1), NoRetry().NextBackoff(1))
+	assert.Equal(t, 50*time.Millisecond, LinearRetry(50*time.Millisecond).NextBackoff(10))
+
+	exp := ExponentialRetry(10*time.Millisecond, 100*time.Millisecond)
+	assert.Equal(t, 10*time.Millisecond, exp.NextBackoff(1))
+	assert.Equal(t, 20*time.Millisecond, exp.NextBackoff(2))
+	assert.Equal(t, 80*time.Millisecond, exp.NextBackoff(4))
+	assert.Equal(t, 100*time.Millisecond, exp.NextBackoff(5))
+	assert.Equal(t, 100*time.Millisecond, exp.NextBackoff(100))
+
+	jitter := JitterRetry(LinearRetry(100 * time.Millisecond))
+	for i := 1; i < 100; i++ {
+		d := jitter.NextBackoff(i)
+		assert.True(t, d >= 50*time.Millisecond && d < 100*time.Millisecond, d)
+	}
+	assert.Equal(t, time.Duration(-1), JitterRetry(NoRetry()).NextBackoff(1))
+
+	limit := LimitRetry(LinearRetry(time.Millisecond), 2)
+	assert.Equal(t, time.Millisecond, limit.NextBackoff(2))
+	assert.Equal(t, time.Duration(-1), limit.NextBackoff(3))
+}
+
+func TestLockWait(t *testing.T) {
+	ctx := context.Background()
+	holder := NewRedisLock(Client, "wait1")
+	ok, err := holder.Lock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	waiter := NewRedisLock(Client, "wait1", WithRetryStrategy(LimitRetry(LinearRetry(10*time.Millisecond), 3)))
+	assert.ErrorIs(t, waiter.LockWait(ctx), ErrNotObtained)
+
+	ok, err = waiter.TryLock(ctx, 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	waiter = NewRedisLock(Client, "wait1", WithRetryStrategy(JitterRetry(ExponentialRetry(10*time.Millisecond, 50*time.Millisecond))))
+	ok, err = waiter.TryLock(ctx, 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = waiter.TryLock(cctx, time.Second)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = holder.Unlock(ctx)
+	}()
+	assert.NoError(t, waiter.LockWait(ctx))
+	ok, err = waiter.Unlock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestLockWaitNotify(t *testing.T) {
+	ctx := context.Background()
+	holder := NewRedisLock(Client, "wait2", WithNotify())
+	ok, err := holder.Lock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// 重试间隔很长, 只能通过释放通知获取锁
+	waiter := NewRedisLock(Client, "wait2", WithNotify(), WithRetryStrategy(LinearRetry(time.Minute)))
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = holder.Unlock(ctx)
+	}()
+	start := time.Now()
+	ok, err = waiter.TryLock(ctx, 5*time.Second)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Less(t, time.Since(start), time.Second)
+	_, _ = waiter.Unlock(ctx)
+}
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	lock := NewRedisLock(Client, "refresh1", WithTTL(time.Second))
+	ok, err := lock.Refresh(ctx, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = lock.Lock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = lock.Refresh(ctx, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, Client.PTTL(ctx, lock.key).Val())
+
+	other := NewRedisLock(Client, "refresh1")
+	ok, err = other.Refresh(ctx, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, _ = lock.Unlock(ctx)
+}
+
+func TestWatchdog(t *testing.T) {
+	ctx := context.Background()
+	lock := NewRedisLock(Client, "watchdog1", WithTTL(time.Second), WithWatchdog(20*time.Millisecond))
+	assert.Nil(t, lock.Lost())
+	ok, err := lock.Lock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// 缩短过期时间后由续期恢复
+	Client.PExpire(ctx, lock.key, 10*time.Second)
+	assert.Eventually(t, func() bool {
+		return Client.PTTL(ctx, lock.key).Val() == time.Second
+	}, time.Second, 10*time.Millisecond)
+
+	// 锁被他人持有后通知丢失
+	Client.Set(ctx, lock.key, "other", time.Second)
+	select {
+	case <-lock.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lost not fired")
+	}
+	ok, err = lock.Unlock(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	Client.Del(ctx, lock.key)
+
+	// 释放锁后停止续期且不通知丢失
+	lock = NewRedisLock(Client, "watchdog1", WithWatchdog(0), WithTTL(30*time.Millisecond))
+	assert.Equal(t, 10*time.Millisecond, lock.interval)
+	ok, err = lock.Lock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	lost := lock.Lost()
+	ok, err = lock.Unlock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	_, _ = lock.Unlock(ctx)
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-lost:
+		t.Fatal("lost fired after unlock")
+	default:
+	}
+
+	// 持有者 ctx 结束后停止续期
+	cctx, cancel := context.WithCancel(ctx)
+	lock = NewRedisLock(Client, "watchdog2", WithTTL(time.Second), WithWatchdog(10*time.Millisecond))
+	ok, err = lock.Lock(cctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	cancel()
+	time.Sleep(30 * time.Millisecond)
+	Client.PExpire(ctx, lock.key, 10*time.Second)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 10*time.Second, Client.PTTL(ctx, lock.key).Val())
+	_, _ = lock.Unlock(ctx)
+}
+
+func TestTryLockWatchdog(t *testing.T) {
+	ctx := context.Background()
+	lock := NewRedisLock(Client, "watchdog3", WithTTL(100*time.Millisecond), WithWatchdog(20*time.Millisecond))
+	ok, err := lock.TryLock(ctx, 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// 持有时间超过 ttl, 续期仍在进行
+	time.Sleep(200 * time.Millisecond)
+	Client.PExpire(ctx, lock.key, 10*time.Second)
+	assert.Eventually(t, func() bool {
+		return Client.PTTL(ctx, lock.key).Val() == 100*time.Millisecond
+	}, time.Second, 10*time.Millisecond)
+
+	Client.Set(ctx, lock.key, "other", time.Second)
+	select {
+	case <-lock.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lost not fired")
+	}
+	Client.Del(ctx, lock.key)
+}
@@ -3,6 +3,7 @@ package lock
 import (
 	"context"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -16,6 +17,12 @@ type RedisLock struct {
 	token  string
 	rdb    *redis.Client
 	ttl    time.Duration
+	retry  RetryStrategy
+	notify bool
+
+	mu       sync.Mutex
+	interval time.Duration // 续期间隔, 为 0 时不续期
+	wd       *watchdog
 }
 
 // Option RedisLock option
@@ -35,6 +42,31 @@ func WithTTL(ttl time.Duration) Option {
 	}
 }
 
+// WithRetryStrategy with retry strategy of LockWait and TryLock, default LinearRetry(100ms)
+func WithRetryStrategy(s RetryStrategy) Option {
+	return func(l *RedisLock) {
+		l.retry = s
+	}
+}
+
+// WithNotify 释放锁时通过 pub/sub 通知等待者立即重试, 而不必等待重试间隔
+func WithNotify() Option {
+	return func(l *RedisLock) {
+		l.notify = true
+	}
+}
+
+// WithWatchdog 获取锁后在持有者 ctx 结束前按 interval 自动续期, 续期失败时 Lost 通知持有者,
+// interval 为 0 时使用 ttl/3
+func WithWatchdog(interval time.Duration) Option {
+	return func(l *RedisLock) {
+		l.interval = interval
+		if l.interval <= 0 {
+			l.interval = -1
+		}
+	}
+}
+
 // NewRedisLock new a redis lock instance
 func NewRedisLock(rdb *redis.Client, key string, opts ...Option) *RedisLock {
 	opt := &RedisLock{
@@ -42,28 +74,53 @@ func NewRedisLock(rdb *redis.Client, key string, opts ...Option) *RedisLock {
 		token:  genToken(),
 		prefix: _prefix,
 		ttl:    _ttl,
+		retry:  LinearRetry(100 * time.Millisecond),
 	}
 	for _, f := range opts {
 		f(opt)
 	}
 	opt.key = strings.Join([]string{opt.prefix, key}, ":")
+	if opt.interval < 0 {
+		opt.interval = opt.ttl / 3
+	}
 	return opt
 }
 
 // Lock acquires the lock.
 func (l *RedisLock) Lock(ctx context.Context) (bool, error) {
+	return l.lock(ctx, ctx)
+}
+
+// lock 使用 ctx 获取锁, 续期持续到持有者的 holder 结束
+func (l *RedisLock) lock(ctx, holder context.Context) (bool, error) {
 	isSet, err := l.rdb.SetNX(ctx, l.key, l.token, l.ttl).Result()
 	if err == redis.Nil {
 		return false, nil
 	} else if err != nil {
 		return false, errors.Wrapf(err, "[lock] acquires the lock err, key: %s", l.key)
 	}
+	if isSet && l.interval > 0 {
+		l.mu.Lock()
+		if l.wd != nil {
+			l.wd.Stop()
+		}
+		l.wd = startWatchdog(holder, l.interval, l.ttl, func(ctx context.Context) (bool, error) {
+			return l.Refresh(ctx, l.ttl)
+		})
+		l.mu.Unlock()
+	}
 	return isSet, nil
 }
 
 // Unlock del the lock.
 // NOTE: token 一致才会执行删除，避免误删，这里用了lua脚本进行事务处理
 func (l *RedisLock) Unlock(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	if l.wd != nil {
+		l.wd.Stop()
+	}
+	l.mu.Unlock()
+
 	luaScript := "if redis.call('GET',KEYS[1]) == ARGV[1] then return redis.call('DEL',KEYS[1]) else return 0 end"
 	ret, err := l.rdb.Eval(ctx, luaScript, []string{l.key}, l.token).Result()
 	if err != nil {
@@ -73,5 +130,89 @@ func (l *RedisLock) Unlock(ctx context.Context) (bool, error) {
 	if !ok {
 		return false, nil
 	}
+	if reply == 1 && l.notify {
+		// 通知失败时等待者按重试策略获取锁
+		_ = l.rdb.Publish(ctx, l.channel(), l.token).Err()
+	}
 	return reply == 1, nil
 }
+
+// Refresh 将锁的过期时间重置为 ttl, token 不一致(锁已过期或被他人持有)时返回 false
+func (l *RedisLock) Refresh(ctx context.Context, ttl time.Duration) (bool, error) {
+	luaScript := "if redis.call('GET',KEYS[1]) == ARGV[1] then return redis.call('PEXPIRE',KEYS[1],ARGV[2]) else return 0 end"
+	ret, err := l.rdb.Eval(ctx, luaScript, []string{l.key}, l.token, ttl.Milliseconds()).Result()
+	if err != nil {
+		return false, errors.Wrapf(err, "[lock] refresh the lock err, key: %s", l.key)
+	}
+	reply, ok := ret.(int64)
+	return ok && reply == 1, nil
+}
+
+// Lost 启用 WithWatchdog 时, 续期失败(锁已丢失)后关闭的通道, 持有者应中止工作
+// 未获取到锁时返回 nil
+func (l *RedisLock) Lost() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.wd == nil {
+		return nil
+	}
+	return l.wd.lost
+}
+
+// LockWait 阻塞获取锁, 失败后按重试策略重试, 直到获取成功, 重试策略结束(ErrNotObtained)或 ctx 结束
+func (l *RedisLock) LockWait(ctx context.Context) error {
+	return l.lockWait(ctx, ctx)
+}
+
+// lockWait 使用 ctx 阻塞获取锁, 续期持续到持有者的 holder 结束
+func (l *RedisLock) lockWait(ctx, holder context.Context) error {
+	var notify <-chan struct{}
+	if l.notify {
+		// 先订阅再尝试获取锁, 避免错过释放通知
+		sub := l.rdb.Subscribe(ctx, l.channel())
+		defer sub.Close()
+		if _, err := sub.Receive(ctx); err != nil {
+			return errors.Wrapf(err, "[lock] subscribe unlock notify err, key: %s", l.key)
+		}
+		notify = forward(sub.Channel())
+	}
+	return wait(ctx, l.retry, notify, func(ctx context.Context) (bool, error) {
+		return l.lock(ctx, holder)
+	})
+}
+
+// TryLock 在 timeout 内阻塞获取锁, 超时返回 false, timeout 只限制获取, 续期持续到 ctx 结束
+func (l *RedisLock) TryLock(ctx context.Context, timeout time.Duration) (bool, error) {
+	tctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := l.lockWait(tctx, ctx)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrNotObtained):
+		return false, nil
+	case ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded):
+		return false, nil
+	}
+	return false, err
+}
+
+// channel 释放锁的通知频道
+func (l *RedisLock) channel() string {
+	return l.key + ":notify"
+}
+
+// forward 将订阅消息转换为通知, 订阅关闭后结束
+func forward(ch <-chan *redis.Message) <-chan struct{} {
+	notify := make(chan struct{}, 1)
+	go func() {
+		for range ch {
+			select {
+			case notify <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return notify
+}
@@ -0,0 +1,205 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// _driftFactor 时钟漂移系数, 有效期扣除 ttl * _driftFactor + 2ms
+	_driftFactor = 0.01
+	// _nodeTimeout 单个节点的最大请求时间, 避免故障节点占用锁的有效期
+	_nodeTimeout = 100 * time.Millisecond
+)
+
+var (
+	// KEYS[1] 锁, ARGV[1] token
+	redUnlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+	// fencingSetScript 将 fencing 计数器提升到 ARGV[1], 已经更大时保持不变
+	fencingSetScript = redis.NewScript(`
+local v = tonumber(redis.call('GET', KEYS[1]) or '0')
+if tonumber(ARGV[1]) > v then
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return 1`)
+)
+
+// Redlock 多个独立 redis 节点上的分布式锁, 在多数节点上获取成功且扣除耗时及时钟漂移后仍有效时才算持有,
+// 单个节点故障或主从切换不会导致锁被两个持有者同时获取
+//
+// 每次获取成功时生成单调递增的 fencing token, 下游存储可据此拒绝过期持有者的写入
+type Redlock struct {
+	base    *RedisLock
+	clients []*redis.Client
+	quorum  int
+
+	mu      sync.Mutex
+	fencing int64
+	until   time.Time
+}
+
+// NewRedlock new a redlock instance over independent clients, 支持 WithPrefix, WithTTL 及 WithRetryStrategy,
+// 多个等待者同时竞争时建议使用 JitterRetry
+func NewRedlock(clients []*redis.Client, key string, opts ...Option) *Redlock {
+	l := &Redlock{clients: clients, quorum: len(clients)/2 + 1}
+	if len(clients) != 0 {
+		l.base = NewRedisLock(clients[0], key, opts...)
+	} else {
+		l.base = NewRedisLock(nil, key, opts...)
+	}
+	return l
+}
+
+// Lock acquires the lock on the quorum of nodes, 失败时释放已获取的节点
+func (l *Redlock) Lock(ctx context.Context) (bool, error) {
+	if len(l.clients) == 0 {
+		return false, errors.New("[lock] redlock has no redis client")
+	}
+
+	start := time.Now()
+	acquired, err := l.each(ctx, l.clients, func(ctx context.Context, rdb *redis.Client) (bool, error) {
+		return rdb.SetNX(ctx, l.base.key, l.base.token, l.base.ttl).Result()
+	})
+
+	drift := time.Duration(float64(l.base.ttl)*_driftFactor) + 2*time.Millisecond
+	validity := l.base.ttl - time.Since(start) - drift
+	if len(acquired) < l.quorum || validity <= 0 {
+		l.release(ctx)
+		if len(acquired) < l.quorum && err != nil {
+			return false, errors.Wrapf(err, "[lock] acquires the redlock err, key: %s", l.base.key)
+		}
+		return false, nil
+	}
+
+	fencing, err := l.fence(ctx, acquired)
+	if err != nil {
+		l.release(ctx)
+		return false, errors.Wrapf(err, "[lock] gen fencing token err, key: %s", l.base.key)
+	}
+
+	l.mu.Lock()
+	l.fencing = fencing
+	l.until = start.Add(validity)
+	l.mu.Unlock()
+	return true, nil
+}
+
+// fence 在获取到锁的节点上 INCR fencing 计数器, 取最大值作为 fencing token,
+// 再将这些节点的计数器提升到该值, 任意两个多数派至少有一个公共节点, 从而保证后续获取的 token 更大
+func (l *Redlock) fence(ctx context.Context, clients []*redis.Client) (int64, error) {
+	key := l.base.key + ":fencing"
+	var (
+		mu  sync.Mutex
+		max int64
+	)
+	ok, err := l.each(ctx, clients, func(ctx context.Context, rdb *redis.Client) (bool, error) {
+		n, err := rdb.Incr(ctx, key).Result()
+		if err != nil {
+			return false, err
+		}
+		mu.Lock()
+		if n > max {
+			max = n
+		}
+		mu.Unlock()
+		return true, nil
+	})
+	if len(ok) < l.quorum {
+		return 0, quorumErr(err)
+	}
+
+	ok, err = l.each(ctx, ok, func(ctx context.Context, rdb *redis.Client) (bool, error) {
+		return true, fencingSetScript.Run(ctx, rdb, []string{key}, max).Err()
+	})
+	if len(ok) < l.quorum {
+		return 0, quorumErr(err)
+	}
+	return max, nil
+}
+
+// Unlock 释放所有节点上的锁, 多数节点释放成功时返回 true
+func (l *Redlock) Unlock(ctx context.Context) (bool, error) {
+	released, err := l.each(ctx, l.clients, func(ctx context.Context, rdb *redis.Client) (bool, error) {
+		n, err := redUnlockScript.Run(ctx, rdb, []string{l.base.key}, l.base.token).Int()
+		return n == 1, err
+	})
+
+	l.mu.Lock()
+	l.until = time.Time{}
+	l.mu.Unlock()
+	if len(released) < l.quorum && err != nil {
+		return false, errors.Wrapf(err, "[lock] release the redlock err, key: %s", l.base.key)
+	}
+	return len(released) >= l.quorum, nil
+}
+
+// LockWait 阻塞获取锁, 见 RedisLock.LockWait
+func (l *Redlock) LockWait(ctx context.Context) error {
+	return wait(ctx, l.base.retry, nil, l.Lock)
+}
+
+// FencingToken 最近一次获取成功时的 fencing token, 未获取过时为 0
+func (l *Redlock) FencingToken() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.fencing
+}
+
+// Until 锁的有效期截止时间, 未持有时为零值
+func (l *Redlock) Until() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.until
+}
+
+// release 尽力释放所有节点上的锁
+func (l *Redlock) release(ctx context.Context) {
+	_, _ = l.each(context.WithoutCancel(ctx), l.clients, func(ctx context.Context, rdb *redis.Client) (bool, error) {
+		return true, redUnlockScript.Run(ctx, rdb, []string{l.base.key}, l.base.token).Err()
+	})
+}
+
+func quorumErr(err error) error {
+	if err == nil {
+		return errors.New("not reach quorum")
+	}
+	return errors.Wrap(err, "not reach quorum")
+}
+
+// each 并发在各节点上执行 fn, 返回 fn 成功的节点及最后一个错误
+func (l *Redlock) each(ctx context.Context, clients []*redis.Client, fn func(ctx context.Context, rdb *redis.Client) (bool, error)) ([]*redis.Client, error) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		ok      []*redis.Client
+		lastErr error
+	)
+	for _, rdb := range clients {
+		wg.Add(1)
+		go func(rdb *redis.Client) {
+			defer wg.Done()
+			nctx, cancel := context.WithTimeout(ctx, _nodeTimeout)
+			defer cancel()
+
+			success, err := fn(nctx, rdb)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				lastErr = err
+			} else if success {
+				ok = append(ok, rdb)
+			}
+		}(rdb)
+	}
+	wg.Wait()
+	return ok, lastErr
+}
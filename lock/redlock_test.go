@@ -0,0 +1,109 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redis2 "github.com/binbinly/pkg/storage/redis"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Lock = (*Redlock)(nil)
+
+// deadClient 不可用的节点
+func deadClient() *redis.Client {
+	return redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+}
+
+func TestRedlock(t *testing.T) {
+	ctx := context.Background()
+	clients := []*redis.Client{redis2.InitTestRedis(), redis2.InitTestRedis(), redis2.InitTestRedis()}
+
+	l1 := NewRedlock(clients, "redlock1", WithTTL(10*time.Second))
+	l2 := NewRedlock(clients, "redlock1", WithRetryStrategy(LimitRetry(LinearRetry(time.Millisecond), 2)))
+	ok, err := l1.Lock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), l1.FencingToken())
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), l1.Until(), 200*time.Millisecond)
+
+	ok, err = l2.Lock(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.ErrorIs(t, l2.LockWait(ctx), ErrNotObtained)
+	assert.Equal(t, int64(0), l2.FencingToken())
+
+	ok, err = l1.Unlock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, l1.Until().IsZero())
+	for _, c := range clients {
+		assert.Equal(t, int64(0), c.Exists(ctx, l1.base.key).Val())
+	}
+
+	assert.NoError(t, l2.LockWait(ctx))
+	assert.Equal(t, int64(2), l2.FencingToken())
+	ok, err = l2.Unlock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestRedlockQuorum(t *testing.T) {
+	ctx := context.Background()
+	a, b, c := redis2.InitTestRedis(), redis2.InitTestRedis(), redis2.InitTestRedis()
+
+	// 一个节点故障时仍可获取
+	l := NewRedlock([]*redis.Client{a, b, deadClient()}, "redlock2")
+	ok, err := l.Lock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = l.Unlock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// 多数节点故障时失败, 并释放已获取的节点
+	l = NewRedlock([]*redis.Client{a, deadClient(), deadClient()}, "redlock2")
+	ok, err = l.Lock(ctx)
+	assert.Error(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), a.Exists(ctx, l.base.key).Val())
+
+	// 少数节点被他人持有时仍可获取
+	c.Set(ctx, l.base.key, "other", time.Minute)
+	l = NewRedlock([]*redis.Client{a, b, c}, "redlock2")
+	ok, err = l.Lock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	_, _ = l.Unlock(ctx)
+	assert.Equal(t, "other", c.Get(ctx, l.base.key).Val())
+
+	// 扣除时钟漂移后已过期时失败
+	l = NewRedlock([]*redis.Client{a, b}, "redlock3", WithTTL(time.Millisecond))
+	ok, err = l.Lock(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), a.Exists(ctx, l.base.key).Val())
+
+	_, err = NewRedlock(nil, "redlock4").Lock(ctx)
+	assert.Error(t, err)
+}
+
+func TestRedlockFencing(t *testing.T) {
+	ctx := context.Background()
+	a, b, c := redis2.InitTestRedis(), redis2.InitTestRedis(), redis2.InitTestRedis()
+
+	// 不同的多数派交替获取, fencing token 仍然单调递增
+	quorums := [][]*redis.Client{{a, b, deadClient()}, {deadClient(), b, c}, {a, deadClient(), c}, {a, b, c}}
+	var last int64
+	for i := 0; i < 8; i++ {
+		l := NewRedlock(quorums[i%len(quorums)], "redlock5")
+		ok, err := l.Lock(ctx)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Greater(t, l.FencingToken(), last)
+		last = l.FencingToken()
+		_, _ = l.Unlock(ctx)
+	}
+}
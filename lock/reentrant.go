@@ -0,0 +1,80 @@
+package lock
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// KEYS[1] 锁, ARGV[1] 持有者 token, ARGV[2] 过期时间(毫秒)
+	reentrantLockScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 or redis.call('HEXISTS', KEYS[1], ARGV[1]) == 1 then
+	redis.call('HINCRBY', KEYS[1], ARGV[1], 1)
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0`)
+
+	// 返回 -1 未持有, 0 计数减一, 1 已释放
+	reentrantUnlockScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return -1
+end
+if redis.call('HINCRBY', KEYS[1], ARGV[1], -1) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 0
+end
+redis.call('DEL', KEYS[1])
+return 1`)
+)
+
+type ownerKey struct{}
+
+// WithOwner 在 ctx 中保存持有者 token, 同一流程中使用该 ctx 的 ReentrantLock 视为同一持有者
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// ReentrantLock 可重入锁, 在 redis hash 中按持有者 token 记录获取次数,
+// 同一持有者可多次获取, 释放相同次数后锁才会删除
+type ReentrantLock struct {
+	base *RedisLock
+}
+
+// NewReentrantLock new a reentrant lock instance, 支持 WithPrefix, WithTTL 及 WithRetryStrategy
+func NewReentrantLock(rdb *redis.Client, key string, opts ...Option) *ReentrantLock {
+	return &ReentrantLock{base: NewRedisLock(rdb, key, opts...)}
+}
+
+// owner 持有者 token, ctx 中没有时使用实例的 token
+func (l *ReentrantLock) owner(ctx context.Context) string {
+	if owner, ok := ctx.Value(ownerKey{}).(string); ok && owner != "" {
+		return owner
+	}
+	return l.base.token
+}
+
+// Lock acquires the lock, 已持有时计数加一并重置过期时间
+func (l *ReentrantLock) Lock(ctx context.Context) (bool, error) {
+	ret, err := reentrantLockScript.Run(ctx, l.base.rdb, []string{l.base.key}, l.owner(ctx), l.base.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, errors.Wrapf(err, "[lock] acquires the reentrant lock err, key: %s", l.base.key)
+	}
+	return ret == 1, nil
+}
+
+// Unlock 计数减一, 减为 0 时删除锁, 未持有时返回 false
+func (l *ReentrantLock) Unlock(ctx context.Context) (bool, error) {
+	ret, err := reentrantUnlockScript.Run(ctx, l.base.rdb, []string{l.base.key}, l.owner(ctx), l.base.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, errors.Wrapf(err, "[lock] release the reentrant lock err, key: %s", l.base.key)
+	}
+	return ret >= 0, nil
+}
+
+// LockWait 阻塞获取锁, 见 RedisLock.LockWait
+func (l *ReentrantLock) LockWait(ctx context.Context) error {
+	return wait(ctx, l.base.retry, nil, l.Lock)
+}
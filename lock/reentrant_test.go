@@ -0,0 +1,127 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ Lock = (*ReentrantLock)(nil)
+	_ Lock = (*RWLock)(nil)
+)
+
+func TestReentrantLock(t *testing.T) {
+	ctx := context.Background()
+	lock := NewReentrantLock(Client, "reentrant1", WithTTL(time.Minute))
+	other := NewReentrantLock(Client, "reentrant1", WithRetryStrategy(LimitRetry(LinearRetry(time.Millisecond), 2)))
+
+	for i := 0; i < 3; i++ {
+		ok, err := lock.Lock(ctx)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+	ok, err := other.Lock(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.ErrorIs(t, other.LockWait(ctx), ErrNotObtained)
+	ok, err = other.Unlock(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// 释放相同次数后才删除锁
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, int64(1), Client.Exists(ctx, lock.base.key).Val())
+		ok, err = lock.Unlock(ctx)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, int64(0), Client.Exists(ctx, lock.base.key).Val())
+	ok, err = lock.Unlock(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, other.LockWait(ctx))
+	_, _ = other.Unlock(ctx)
+}
+
+func TestReentrantLockOwner(t *testing.T) {
+	ctx := WithOwner(context.Background(), "job-1")
+
+	// 同一流程中的不同实例视为同一持有者
+	outer := NewReentrantLock(Client, "reentrant2")
+	inner := NewReentrantLock(Client, "reentrant2")
+	ok, err := outer.Lock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = inner.Lock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = inner.Lock(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _ = inner.Unlock(ctx)
+	_, _ = outer.Unlock(ctx)
+	assert.Equal(t, int64(0), Client.Exists(ctx, outer.base.key).Val())
+}
+
+func TestRWLock(t *testing.T) {
+	ctx := context.Background()
+	r1 := NewRWLock(Client, "rw1")
+	r2 := NewRWLock(Client, "rw1")
+	w := NewRWLock(Client, "rw1", WithRetryStrategy(LimitRetry(LinearRetry(time.Millisecond), 2)))
+
+	// 多个读者
+	ok, err := r1.RLock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = r2.RLock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = r1.RLock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// 有读者时写者失败
+	ok, err = w.Lock(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.ErrorIs(t, w.LockWait(ctx), ErrNotObtained)
+	ok, err = w.RUnlock(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	for _, r := range []*RWLock{r1, r2, r1} {
+		ok, err = r.RUnlock(ctx)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, int64(0), Client.Exists(ctx, w.base.key).Val())
+
+	// 写者独占
+	ok, err = w.Lock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = r1.RLock(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = r1.Lock(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = r1.Unlock(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Unlock(ctx)
+	}()
+	assert.NoError(t, r1.RLockWait(ctx))
+	ok, err = r1.RUnlock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,116 @@
+package lock
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotObtained 重试策略结束后仍未获取到锁
+var ErrNotObtained = errors.New("lock: not obtained")
+
+// RetryStrategy 获取锁失败后的重试策略, 需要可并发使用
+type RetryStrategy interface {
+	// NextBackoff 第 n 次(从 1 开始)重试前的等待时间, 小于 0 时停止重试
+	NextBackoff(n int) time.Duration
+}
+
+// RetryFunc 函数形式的重试策略
+type RetryFunc func(n int) time.Duration
+
+// NextBackoff implements RetryStrategy
+func (f RetryFunc) NextBackoff(n int) time.Duration {
+	return f(n)
+}
+
+// NoRetry 不重试
+func NoRetry() RetryStrategy {
+	return RetryFunc(func(int) time.Duration {
+		return -1
+	})
+}
+
+// LinearRetry 每次重试前等待固定时间
+func LinearRetry(interval time.Duration) RetryStrategy {
+	return RetryFunc(func(int) time.Duration {
+		return interval
+	})
+}
+
+// ExponentialRetry 指数退避, 第 n 次重试前等待 min * 2^(n-1), 不超过 max
+func ExponentialRetry(min, max time.Duration) RetryStrategy {
+	return RetryFunc(func(n int) time.Duration {
+		d := min
+		for i := 1; i < n && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	})
+}
+
+// JitterRetry 在 s 的等待时间上增加抖动, 实际等待时间为 [d/2, d) 内的随机值, 避免多个等待者同时重试
+func JitterRetry(s RetryStrategy) RetryStrategy {
+	return RetryFunc(func(n int) time.Duration {
+		d := s.NextBackoff(n)
+		if d <= 1 {
+			return d
+		}
+		half := d / 2
+		return half + time.Duration(rand.Int63n(int64(d-half)))
+	})
+}
+
+// LimitRetry 最多重试 max 次
+func LimitRetry(s RetryStrategy, max int) RetryStrategy {
+	return RetryFunc(func(n int) time.Duration {
+		if n > max {
+			return -1
+		}
+		return s.NextBackoff(n)
+	})
+}
+
+// wait 按重试策略反复尝试获取锁, 收到 notify 时立即重试, ctx 结束时返回 ctx 的错误
+func wait(ctx context.Context, strategy RetryStrategy, notify <-chan struct{}, try func(ctx context.Context) (bool, error)) error {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for n := 1; ; n++ {
+		ok, err := try(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		backoff := strategy.NextBackoff(n)
+		if backoff < 0 {
+			return ErrNotObtained
+		}
+		if timer == nil {
+			timer = time.NewTimer(backoff)
+		} else {
+			timer.Reset(backoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+			if !timer.Stop() {
+				<-timer.C
+			}
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package lock
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// 读写锁保存在 hash 中, mode 字段为 read 或 write, 其余字段为持有者 token 及获取次数
+// KEYS[1] 锁, ARGV[1] 持有者 token, ARGV[2] 过期时间(毫秒)
+var (
+	rLockScript = redis.NewScript(`
+local mode = redis.call('HGET', KEYS[1], 'mode')
+if mode == false then
+	redis.call('HSET', KEYS[1], 'mode', 'read')
+	mode = 'read'
+end
+if mode == 'read' then
+	redis.call('HINCRBY', KEYS[1], ARGV[1], 1)
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0`)
+
+	rUnlockScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'mode') ~= 'read' or redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+if redis.call('HINCRBY', KEYS[1], ARGV[1], -1) <= 0 then
+	redis.call('HDEL', KEYS[1], ARGV[1])
+end
+if redis.call('HLEN', KEYS[1]) <= 1 then
+	redis.call('DEL', KEYS[1])
+else
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 1`)
+
+	wLockScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	redis.call('HSET', KEYS[1], 'mode', 'write', ARGV[1], 1)
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0`)
+
+	wUnlockScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'mode') == 'write' and redis.call('HEXISTS', KEYS[1], ARGV[1]) == 1 then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+)
+
+// RWLock 读写锁, 同一时间允许多个读者或一个写者
+//
+// NOTE: 所有读者共用一个过期时间, 每次获取读锁都会重置; 持续有读者时写者无法获取锁
+type RWLock struct {
+	base *RedisLock
+}
+
+// NewRWLock new a read/write lock instance, 支持 WithPrefix, WithTTL 及 WithRetryStrategy
+func NewRWLock(rdb *redis.Client, key string, opts ...Option) *RWLock {
+	return &RWLock{base: NewRedisLock(rdb, key, opts...)}
+}
+
+// RLock acquires the read lock, 没有写者时成功
+func (l *RWLock) RLock(ctx context.Context) (bool, error) {
+	return l.run(ctx, rLockScript, "acquires the read lock")
+}
+
+// RUnlock releases the read lock
+func (l *RWLock) RUnlock(ctx context.Context) (bool, error) {
+	return l.run(ctx, rUnlockScript, "release the read lock")
+}
+
+// Lock acquires the write lock, 没有读者及写者时成功
+func (l *RWLock) Lock(ctx context.Context) (bool, error) {
+	return l.run(ctx, wLockScript, "acquires the write lock")
+}
+
+// Unlock releases the write lock
+func (l *RWLock) Unlock(ctx context.Context) (bool, error) {
+	return l.run(ctx, wUnlockScript, "release the write lock")
+}
+
+// RLockWait 阻塞获取读锁, 见 RedisLock.LockWait
+func (l *RWLock) RLockWait(ctx context.Context) error {
+	return wait(ctx, l.base.retry, nil, l.RLock)
+}
+
+// LockWait 阻塞获取写锁, 见 RedisLock.LockWait
+func (l *RWLock) LockWait(ctx context.Context) error {
+	return wait(ctx, l.base.retry, nil, l.Lock)
+}
+
+func (l *RWLock) run(ctx context.Context, script *redis.Script, action string) (bool, error) {
+	ret, err := script.Run(ctx, l.base.rdb, []string{l.base.key}, l.base.token, l.base.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, errors.Wrapf(err, "[lock] %s err, key: %s", action, l.base.key)
+	}
+	return ret == 1, nil
+}
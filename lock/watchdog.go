@@ -0,0 +1,70 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// watchdog 持有锁期间按间隔续期, 续期失败时关闭 lost
+type watchdog struct {
+	once sync.Once
+	stop chan struct{}
+	done chan struct{}
+	lost chan struct{}
+}
+
+// startWatchdog 启动续期, refresh 返回 false 或续期错误持续超过 ttl 时认为锁已丢失, ctx 结束时停止续期
+func startWatchdog(ctx context.Context, interval, ttl time.Duration, refresh func(ctx context.Context) (bool, error)) *watchdog {
+	w := &watchdog{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+		lost: make(chan struct{}),
+	}
+	go w.run(ctx, interval, ttl, refresh)
+	return w
+}
+
+func (w *watchdog) run(ctx context.Context, interval, ttl time.Duration, refresh func(ctx context.Context) (bool, error)) {
+	defer close(w.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ok, err := refresh(ctx)
+		switch {
+		case err == nil && ok:
+			last = time.Now()
+			continue
+		case err != nil && ctx.Err() != nil:
+			return
+		case err != nil && time.Since(last) < ttl:
+			// 临时错误, 锁过期前继续重试
+			continue
+		}
+		select {
+		case <-w.stop:
+			// 已释放锁, 续期失败是由于锁已删除
+		default:
+			close(w.lost)
+		}
+		return
+	}
+}
+
+// Stop 停止续期并等待续期协程退出, 可重复调用
+func (w *watchdog) Stop() {
+	w.once.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
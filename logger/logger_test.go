@@ -2,6 +2,11 @@ package logger
 
 import (
 	"testing"
+
+	"github.com/binbinly/pkg/errno"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestLogger(t *testing.T) {
@@ -17,3 +22,19 @@ func TestLogger(t *testing.T) {
 	Infof("test info")
 	Debugf("test debug %d", 1)
 }
+
+func TestErrorField(t *testing.T) {
+	err := pkgerrors.Wrap(errno.ErrNotFound.WithMeta("uid", 1), "find user")
+	enc := zapcore.NewMapObjectEncoder()
+	anyField("err", err).AddTo(enc)
+	assert.Equal(t, map[string]any{
+		"error": "find user: code：10003, msg:：Not found",
+		"code":  10003,
+		"msg":   "Not found",
+		"meta":  map[string]any{"uid": 1},
+	}, enc.Fields["err"])
+
+	enc = zapcore.NewMapObjectEncoder()
+	anyField("err", pkgerrors.New("plain")).AddTo(enc)
+	assert.Equal(t, "plain", enc.Fields["err"])
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -84,7 +85,7 @@ func (l *zapLogger) Init(opts ...Option) error {
 	if l.opts.Fields != nil {
 		fields := make([]zap.Field, 0, len(l.opts.Fields))
 		for k, v := range l.opts.Fields {
-			fields = append(fields, zap.Any(k, v))
+			fields = append(fields, anyField(k, v))
 		}
 		l.zap = l.zap.With(fields...)
 	}
@@ -94,7 +95,7 @@ func (l *zapLogger) Init(opts ...Option) error {
 func (l *zapLogger) Fields(fields map[string]any) Logger {
 	data := make([]zap.Field, 0, len(fields))
 	for k, v := range fields {
-		data = append(data, zap.Any(k, v))
+		data = append(data, anyField(k, v))
 	}
 
 	zl := &zapLogger{
@@ -105,6 +106,22 @@ func (l *zapLogger) Fields(fields map[string]any) Logger {
 	return zl
 }
 
+// anyField 错误链中有实现 zapcore.ObjectMarshaler 的错误时(如 errno.Error), 按结构化字段输出
+func anyField(key string, v any) zap.Field {
+	err, ok := v.(error)
+	if !ok {
+		return zap.Any(key, v)
+	}
+	var m zapcore.ObjectMarshaler
+	if !errors.As(err, &m) {
+		return zap.Any(key, v)
+	}
+	return zap.Object(key, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddString("error", err.Error())
+		return m.MarshalLogObject(enc)
+	}))
+}
+
 func (l *zapLogger) Log(level string, v ...any) {
 	l.zap.Log(getZapLevel(level), fmt.Sprint(v...))
 }
@@ -0,0 +1,121 @@
+// Package grpc 提供 errno 错误与 gRPC 状态自动转换的拦截器
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/binbinly/pkg/errno"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor 将 handler 返回的错误转换为携带业务错误码的 gRPC 状态,
+// 错误消息按 metadata 中的 accept-language 本地化, 非 errno 错误按 errno.ErrInternalServer 处理
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = withLanguage(ctx)
+		resp, err := handler(ctx, req)
+		return resp, toStatusError(ctx, err)
+	}
+}
+
+// StreamServerInterceptor 流式调用的 UnaryServerInterceptor
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := withLanguage(ss.Context())
+		return toStatusError(ctx, handler(srv, &serverStream{ServerStream: ss, ctx: ctx}))
+	}
+}
+
+// UnaryClientInterceptor 将 gRPC 状态错误还原为 *errno.Error, 并透传 ctx 中的语言
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return fromStatusError(invoker(outgoingLanguage(ctx), method, req, reply, cc, opts...))
+	}
+}
+
+// StreamClientInterceptor 流式调用的 UnaryClientInterceptor
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(outgoingLanguage(ctx), desc, cc, method, opts...)
+		if err != nil {
+			return nil, fromStatusError(err)
+		}
+		return &clientStream{ClientStream: cs}, nil
+	}
+}
+
+// toStatusError 错误转换为 gRPC 状态错误, 已是状态错误或 ctx 错误时保持不变
+func toStatusError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := errno.FromError(err); ok {
+		return errno.LocalizeContext(ctx, e).GRPCStatus().Err()
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return errno.LocalizeContext(ctx, err).GRPCStatus().Err()
+}
+
+// fromStatusError gRPC 状态错误还原为 *errno.Error
+func fromStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	s, ok := status.FromError(err)
+	if !ok || s.Code() == codes.OK {
+		return err
+	}
+	return errno.FromGRPCStatus(s)
+}
+
+// withLanguage 使用 metadata 中的 accept-language 作为 ctx 的语言
+func withLanguage(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("accept-language"); len(v) != 0 {
+			return errno.WithLanguage(ctx, v[0])
+		}
+	}
+	return ctx
+}
+
+// outgoingLanguage 将 ctx 中的语言写入 metadata
+func outgoingLanguage(ctx context.Context) context.Context {
+	if lang := errno.Language(ctx); lang != "" {
+		return metadata.AppendToOutgoingContext(ctx, "accept-language", lang)
+	}
+	return ctx
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+type clientStream struct {
+	grpc.ClientStream
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	return fromStatusError(s.ClientStream.RecvMsg(m))
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	return fromStatusError(s.ClientStream.SendMsg(m))
+}
+
+func (s *clientStream) CloseSend() error {
+	return fromStatusError(s.ClientStream.CloseSend())
+}
@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/binbinly/pkg/errno"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	intercept := UnaryServerInterceptor()
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("accept-language", "zh-CN"))
+
+	call := func(err error) *status.Status {
+		_, err = intercept(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+			return nil, err
+		})
+		s, _ := status.FromError(err)
+		return s
+	}
+
+	s := call(errno.ErrNotFound.WithDetails("user"))
+	assert.Equal(t, codes.NotFound, s.Code())
+	assert.Equal(t, "资源不存在", s.Message())
+
+	s = call(errors.New("sql: connection refused"))
+	assert.Equal(t, codes.Internal, s.Code())
+	assert.Equal(t, "服务器内部错误", s.Message())
+
+	s = call(status.Error(codes.Aborted, "aborted"))
+	assert.Equal(t, codes.Aborted, s.Code())
+	assert.Equal(t, codes.DeadlineExceeded, call(context.DeadlineExceeded).Code())
+	assert.Nil(t, call(nil))
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	intercept := UnaryClientInterceptor()
+	ctx := errno.WithLanguage(context.Background(), "en")
+
+	err := intercept(ctx, "/svc/Method", nil, nil, nil, func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		assert.Equal(t, []string{"en"}, md.Get("accept-language"))
+		return errno.ErrInvalidToken.WithDetails("expired").GRPCStatus().Err()
+	})
+	e, ok := errno.FromError(err)
+	assert.True(t, ok)
+	assert.ErrorIs(t, err, errno.ErrInvalidToken)
+	assert.Equal(t, []string{"expired"}, e.Details())
+
+	err = intercept(ctx, "/svc/Method", nil, nil, nil, func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestUnaryClientInterceptorStatusCode(t *testing.T) {
+	intercept := UnaryClientInterceptor()
+	// 非 errno 的状态经过拦截器后保留原状态码
+	for _, code := range []codes.Code{codes.DeadlineExceeded, codes.Unauthenticated, codes.PermissionDenied,
+		codes.Canceled, codes.Aborted, codes.Unavailable} {
+		err := intercept(context.Background(), "/svc/Method", nil, nil, nil, func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return status.Error(code, "remote")
+		})
+		assert.Equal(t, code, status.Code(err), code.String())
+		_, ok := errno.FromError(err)
+		assert.True(t, ok)
+	}
+	assert.ErrorIs(t, intercept(context.Background(), "/svc/Method", nil, nil, nil, func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.Unauthenticated, "remote")
+	}), errno.ErrUnauthorized)
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/binbinly/pkg/codec"
+	"github.com/binbinly/pkg/errno"
+)
+
+// maxBindSize 整块解码时请求体的最大长度
+const maxBindSize = 32 << 20
+
+var (
+	// ErrUnsupportedMediaType 请求体类型不支持
+	ErrUnsupportedMediaType = errors.New("http: unsupported media type")
+	// ErrBodyTooLarge 请求体超过最大长度
+	ErrBodyTooLarge = errors.New("http: request body too large")
+)
+
+// Bind 根据请求的 Content-Type 选择编码解析请求体, 未设置时使用json
+// protobuf 的流式格式带长度前缀, 与客户端发送的 proto.Marshal 结果不同, 因此读取整个请求体后解码
+func Bind(r *http.Request, v any) error {
+	c := codec.Codec(codec.JSONEncoding{})
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		if c = codec.GetCodecByContentType(ct); c == nil {
+			return ErrUnsupportedMediaType
+		}
+	}
+
+	if base, _, _ := strings.Cut(c.Name(), "+"); base == "proto" {
+		buf, err := io.ReadAll(io.LimitReader(r.Body, maxBindSize+1))
+		if err != nil {
+			return err
+		}
+		if len(buf) > maxBindSize {
+			return ErrBodyTooLarge
+		}
+		return c.Unmarshal(buf, v)
+	}
+
+	dec := codec.NewDecoder(c, r.Body)
+	defer dec.Close()
+	return dec.Decode(v)
+}
+
+// Encode 根据请求的 Accept 协商编码并写入响应, 无法协商时使用json
+func Encode(w http.ResponseWriter, r *http.Request, code int, v any) error {
+	c, contentType := codec.Negotiate(r.Header.Get("Accept"))
+	if c == nil {
+		c, contentType = codec.JSONEncoding{}, codec.MIMEJSON
+	}
+	buf, err := c.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	_, err = w.Write(buf)
+	return err
+}
+
+// errorBody 错误响应体
+type errorBody struct {
+	Code    int      `json:"code" msgpack:"code"`
+	Msg     string   `json:"msg" msgpack:"msg"`
+	Details []string `json:"details,omitempty" msgpack:"details,omitempty"`
+}
+
+// EncodeError 将 err 本地化后写入响应, 语言优先使用 ctx 中的语言, 其次为 Accept-Language
+// 非 errno 错误按 errno.ErrInternalServer 处理, 不会暴露错误内容, 统一的响应格式见 response 包
+func EncodeError(w http.ResponseWriter, r *http.Request, err error) error {
+	lang := errno.Language(r.Context())
+	if lang == "" {
+		lang = r.Header.Get("Accept-Language")
+	}
+	e := errno.Localize(err, lang)
+	if e == nil {
+		e = errno.Localize(errno.Success, lang)
+	}
+	return Encode(w, r, e.StatusCode(), errorBody{Code: e.Code(), Msg: e.Msg(), Details: e.Details()})
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/binbinly/pkg/codec"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestBind(t *testing.T) {
+	type user struct {
+		Name string `json:"name" msgpack:"name"`
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"name":"foo"}`))
+	var u user
+	assert.NoError(t, Bind(req, &u))
+	assert.Equal(t, "foo", u.Name)
+
+	buf, _ := codec.MsgpackEncoding{}.Marshal(user{Name: "bar"})
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(buf))
+	req.Header.Set("Content-Type", codec.MIMEMsgpack)
+	assert.NoError(t, Bind(req, &u))
+	assert.Equal(t, "bar", u.Name)
+
+	// protobuf 请求体为 proto.Marshal 的结果
+	buf, _ = proto.Marshal(wrapperspb.String("baz"))
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(buf))
+	req.Header.Set("Content-Type", codec.MIMEProtobuf)
+	var s wrapperspb.StringValue
+	assert.NoError(t, Bind(req, &s))
+	assert.Equal(t, "baz", s.GetValue())
+
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString("a=b"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.ErrorIs(t, Bind(req, &u), ErrUnsupportedMediaType)
+}
@@ -0,0 +1,172 @@
+// Package response 统一的 http 响应格式, 基于 errno 输出成功及错误响应,
+// 可选使用 RFC 7807 application/problem+json 格式输出错误
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/binbinly/pkg/errno"
+	"github.com/binbinly/pkg/transport"
+	thttp "github.com/binbinly/pkg/transport/http"
+)
+
+// MIMEProblemJSON RFC 7807 错误响应类型
+const MIMEProblemJSON = "application/problem+json"
+
+// Body 响应体
+type Body struct {
+	Code      int      `json:"code" msgpack:"code"`
+	Msg       string   `json:"msg" msgpack:"msg"`
+	Data      any      `json:"data,omitempty" msgpack:"data,omitempty"`
+	Details   []string `json:"details,omitempty" msgpack:"details,omitempty"`
+	RequestID string   `json:"request_id,omitempty" msgpack:"request_id,omitempty"`
+}
+
+// Problem RFC 7807 错误响应体, 扩展了业务错误码, details 及请求id
+type Problem struct {
+	Type      string   `json:"type"`
+	Title     string   `json:"title"`
+	Status    int      `json:"status"`
+	Detail    string   `json:"detail,omitempty"`
+	Instance  string   `json:"instance,omitempty"`
+	Code      int      `json:"code"`
+	Details   []string `json:"details,omitempty"`
+	RequestID string   `json:"request_id,omitempty"`
+}
+
+// Option configures the responder
+type Option func(*options)
+
+type options struct {
+	problem     bool
+	problemType string
+	debug       bool
+	requestID   func(r *http.Request) string
+}
+
+// WithProblemJSON 错误使用 RFC 7807 application/problem+json 格式输出,
+// baseURL 不为空时 type 为 baseURL/错误码, 否则为 about:blank
+func WithProblemJSON(baseURL string) Option {
+	return func(o *options) {
+		o.problem = true
+		o.problemType = baseURL
+	}
+}
+
+// WithDebug 调试模式, 非 errno 错误输出内部错误内容, 仅用于开发环境
+// 默认只输出 errno.ErrInternalServer 的消息, 不暴露内部错误内容
+func WithDebug() Option {
+	return func(o *options) {
+		o.debug = true
+	}
+}
+
+// WithRequestID 获取请求id的方法, 默认依次从 context, 请求头及响应头的 X-Request-ID 获取
+func WithRequestID(fn func(r *http.Request) string) Option {
+	return func(o *options) {
+		o.requestID = fn
+	}
+}
+
+// Responder 按统一格式输出响应
+type Responder struct {
+	opts options
+}
+
+// New 实例化
+func New(opts ...Option) *Responder {
+	o := options{requestID: defaultRequestID}
+	for _, f := range opts {
+		f(&o)
+	}
+	return &Responder{opts: o}
+}
+
+func defaultRequestID(r *http.Request) string {
+	if id := transport.RequestIDFromContext(r.Context()); id != "" {
+		return id
+	}
+	return r.Header.Get(transport.HeaderRequestID)
+}
+
+// Success 输出成功响应, 按 Accept 协商编码
+func (p *Responder) Success(w http.ResponseWriter, r *http.Request, data any) error {
+	e := errno.Localize(errno.Success, language(r))
+	return thttp.Encode(w, r, http.StatusOK, Body{
+		Code:      e.Code(),
+		Msg:       e.Msg(),
+		Data:      data,
+		RequestID: p.requestID(w, r),
+	})
+}
+
+// Error 输出错误响应, 状态码为 errno.Error.StatusCode, 消息按 ctx 中的语言或 Accept-Language 本地化
+// 调试模式下非 errno 错误输出错误内容, err 为 nil 时输出成功响应
+func (p *Responder) Error(w http.ResponseWriter, r *http.Request, err error) error {
+	if err == nil {
+		return p.Success(w, r, nil)
+	}
+
+	e := errno.Localize(err, language(r))
+	code, msg := e.Code(), e.Msg()
+	if _, ok := errno.FromError(err); !ok && p.opts.debug {
+		code, msg = errno.DecodeErr(err)
+	}
+	status := e.StatusCode()
+	requestID := p.requestID(w, r)
+
+	if !p.opts.problem {
+		return thttp.Encode(w, r, status, Body{Code: code, Msg: msg, Details: e.Details(), RequestID: requestID})
+	}
+
+	typ := "about:blank"
+	if p.opts.problemType != "" {
+		typ = p.opts.problemType + "/" + strconv.Itoa(code)
+	}
+	buf, err := json.Marshal(Problem{
+		Type:      typ,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    msg,
+		Instance:  r.URL.Path,
+		Code:      code,
+		Details:   e.Details(),
+		RequestID: requestID,
+	})
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", MIMEProblemJSON)
+	w.WriteHeader(status)
+	_, err = w.Write(buf)
+	return err
+}
+
+func (p *Responder) requestID(w http.ResponseWriter, r *http.Request) string {
+	if id := p.opts.requestID(r); id != "" {
+		return id
+	}
+	return w.Header().Get(transport.HeaderRequestID)
+}
+
+// language 优先使用 ctx 中的语言, 其次为 Accept-Language
+func language(r *http.Request) string {
+	if lang := errno.Language(r.Context()); lang != "" {
+		return lang
+	}
+	return r.Header.Get("Accept-Language")
+}
+
+var std = New()
+
+// Success 使用默认配置输出成功响应
+func Success(w http.ResponseWriter, r *http.Request, data any) error {
+	return std.Success(w, r, data)
+}
+
+// Error 使用默认配置输出错误响应
+func Error(w http.ResponseWriter, r *http.Request, err error) error {
+	return std.Error(w, r, err)
+}
@@ -0,0 +1,97 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/binbinly/pkg/errno"
+	"github.com/binbinly/pkg/transport"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(t *testing.T, fn func(w http.ResponseWriter, r *http.Request) error, header map[string]string) (*httptest.ResponseRecorder, map[string]any) {
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	assert.NoError(t, fn(w, req))
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w, body
+}
+
+func TestSuccess(t *testing.T) {
+	w, body := serve(t, func(w http.ResponseWriter, r *http.Request) error {
+		return Success(w, r, map[string]int{"id": 42})
+	}, map[string]string{transport.HeaderRequestID: "req-1"})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, map[string]any{
+		"code":       float64(0),
+		"msg":        "Ok",
+		"data":       map[string]any{"id": float64(42)},
+		"request_id": "req-1",
+	}, body)
+}
+
+func TestError(t *testing.T) {
+	err := pkgerrors.Wrap(errno.ErrNotFound.WithDetails("user"), "find")
+	w, body := serve(t, func(w http.ResponseWriter, r *http.Request) error {
+		return Error(w, r, err)
+	}, map[string]string{"Accept-Language": "zh-CN"})
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, map[string]any{
+		"code":    float64(10003),
+		"msg":     "资源不存在",
+		"details": []any{"user"},
+	}, body)
+
+	// 默认不暴露内部错误内容
+	raw := errors.New("dial tcp: connection refused")
+	w, body = serve(t, func(w http.ResponseWriter, r *http.Request) error {
+		return Error(w, r, raw)
+	}, nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "Internal server error", body["msg"])
+	assert.Equal(t, float64(10000), body["code"])
+
+	// 调试模式输出内部错误内容
+	debug := New(WithDebug())
+	_, body = serve(t, func(w http.ResponseWriter, r *http.Request) error {
+		return debug.Error(w, r, raw)
+	}, nil)
+	assert.Equal(t, "dial tcp: connection refused", body["msg"])
+}
+
+func TestProblemJSON(t *testing.T) {
+	p := New(WithProblemJSON("https://api.example.com/errors"), WithRequestID(func(r *http.Request) string {
+		return "req-2"
+	}))
+	w, body := serve(t, func(w http.ResponseWriter, r *http.Request) error {
+		return p.Error(w, r, errno.ErrInvalidToken)
+	}, nil)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, MIMEProblemJSON, w.Header().Get("Content-Type"))
+	assert.Equal(t, map[string]any{
+		"type":       "https://api.example.com/errors/10015",
+		"title":      "Unauthorized",
+		"status":     float64(401),
+		"detail":     "Invalid token",
+		"instance":   "/users/42",
+		"code":       float64(10015),
+		"request_id": "req-2",
+	}, body)
+
+	_, body = serve(t, func(w http.ResponseWriter, r *http.Request) error {
+		return New(WithProblemJSON("")).Error(w, r, errno.ErrNotFound)
+	}, nil)
+	assert.Equal(t, "about:blank", body["type"])
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/binbinly/pkg/errno"
 	"github.com/binbinly/pkg/logger"
 	"github.com/gorilla/websocket"
 )
@@ -57,12 +58,13 @@ type wsConnection struct {
 }
 
 // NewConnect 创建连接的方法
-func NewConnect(s *wsServer, conn *websocket.Conn, id uint64, uid int) Connection {
+func NewConnect(s *wsServer, r *http.Request, conn *websocket.Conn, id uint64, uid int) Connection {
 	return &wsConnection{
 		server:  s,
 		id:      id,
 		uid:     uid,
 		conn:    conn,
+		request: r,
 		msgChan: make(chan []byte, s.Options().MaxMsgChanLen),
 	}
 }
@@ -136,7 +138,7 @@ func (c *wsConnection) startReader() {
 				_ = c.AsyncSend(context.Background(), 0, []byte("pong"))
 			} else {
 				// 构建当前客户端请求的request数据
-				req, err := NewRequest(c, buff)
+				req, err := NewCodecRequest(c, c.server.Options().Codec, buff)
 				if err != nil {
 					logger.Warnf("[ws.read] data format err:%v", err)
 					return
@@ -149,7 +151,12 @@ func (c *wsConnection) startReader() {
 
 // Start 启动连接，让当前连接开始工作
 func (c *wsConnection) Start() {
-	c.ctx, c.cancel = context.WithCancel(context.Background())
+	ctx := context.Background()
+	if c.request != nil {
+		// 握手请求的 Accept-Language 作为连接的语言, 用于本地化错误消息
+		ctx = errno.WithLanguage(ctx, c.request.Header.Get("Accept-Language"))
+	}
+	c.ctx, c.cancel = context.WithCancel(ctx)
 
 	if c.server.Options().OnConnStart != nil {
 		c.server.Options().OnConnStart(c)
@@ -1,8 +1,12 @@
 package ws
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/binbinly/pkg/codec"
 )
 
 var defOptions = &Options{
@@ -17,6 +21,7 @@ var defOptions = &Options{
 	ReadBufferSize:   1024,
 	WriteBufferSize:  1024,
 	WriteWait:        10 * time.Second,
+	Codec:            codec.JSONEncoding{},
 }
 
 type AuthHandler = func(r *http.Request, sid string, cid uint64) (uid int, ok bool)
@@ -36,6 +41,7 @@ type Options struct {
 	ReadBufferSize   int           //接收缓冲区
 	WriteBufferSize  int           //发送缓冲区
 	WriteWait        time.Duration //写入客户端超时
+	Codec            codec.Codec   //消息编解码器
 
 	Router      *Engine               //请求路由
 	OnConnStart func(conn Connection) //该Server的连接创建开始时Hook函数
@@ -121,6 +127,27 @@ func WithWriteWait(d time.Duration) Option {
 	}
 }
 
+// WithCodec 通过注册名选择消息编解码器, 如: json, msgpack, msgpack+zstd
+// 消息的 data 需要解码为 any, 只支持 json, msgpack 及其压缩组合, 未注册或不支持的编码(proto, gob) panic
+func WithCodec(name string) Option {
+	c := codec.GetCodec(name)
+	if c == nil {
+		panic(fmt.Sprintf("ws: codec %q not registered", name))
+	}
+	if !isMessageCodec(c) {
+		panic(fmt.Sprintf("ws: codec %q cannot decode message data", name))
+	}
+	return func(o *Options) {
+		o.Codec = c
+	}
+}
+
+// isMessageCodec 编码是否可以解码到 any, proto 需要具体的消息类型, gob 无法解码 any 字段
+func isMessageCodec(c codec.Codec) bool {
+	base, _, _ := strings.Cut(c.Name(), "+")
+	return base == "json" || base == "msgpack"
+}
+
 func WithOnConnStart(f func(conn Connection)) Option {
 	return func(o *Options) {
 		o.OnConnStart = f
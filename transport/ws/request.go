@@ -2,10 +2,16 @@ package ws
 
 import (
 	"encoding/json"
+	"strings"
+
+	"github.com/binbinly/pkg/codec"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 type Request struct {
 	conn  Connection
+	codec codec.Codec // 消息编解码器
+	base  codec.Codec // data 的编解码器, 不含压缩
 	event string
 	data  []byte
 }
@@ -16,17 +22,52 @@ type message struct {
 	Data  json.RawMessage `json:"data"`
 }
 
+// msgpackMessage msgpack 编码的消息结构, data 保留原始编码
+type msgpackMessage struct {
+	Event string             `msgpack:"event"`
+	Data  msgpack.RawMessage `msgpack:"data"`
+}
+
+// codecMessage 回复及其他编码的消息结构, 其他编码的 data 会使用同一编码重新编码
+type codecMessage struct {
+	Event string `json:"event" msgpack:"event"`
+	Data  any    `json:"data" msgpack:"data"`
+}
+
 // NewRequest 实例化请求
 func NewRequest(conn Connection, msg []byte) (*Request, error) {
-	m := &message{}
-	if err := json.Unmarshal(msg, m); err != nil {
-		return nil, err
+	return NewCodecRequest(conn, codec.JSONEncoding{}, msg)
+}
+
+// NewCodecRequest 使用指定编解码器实例化请求,
+// json, msgpack 及其压缩组合只解压一次, data 保留基础编码的原始内容
+func NewCodecRequest(conn Connection, c codec.Codec, msg []byte) (*Request, error) {
+	r := &Request{conn: conn, codec: c, base: c}
+	switch base, _, _ := strings.Cut(c.Name(), "+"); base {
+	case "json":
+		m := &message{}
+		if err := c.Unmarshal(msg, m); err != nil {
+			return nil, err
+		}
+		r.base, r.event, r.data = codec.JSONEncoding{}, m.Event, m.Data
+	case "msgpack":
+		m := &msgpackMessage{}
+		if err := c.Unmarshal(msg, m); err != nil {
+			return nil, err
+		}
+		r.base, r.event, r.data = codec.MsgpackEncoding{}, m.Event, m.Data
+	default:
+		m := &codecMessage{}
+		if err := c.Unmarshal(msg, m); err != nil {
+			return nil, err
+		}
+		data, err := c.Marshal(m.Data)
+		if err != nil {
+			return nil, err
+		}
+		r.event, r.data = m.Event, data
 	}
-	return &Request{
-		conn:  conn,
-		event: m.Event,
-		data:  m.Data,
-	}, nil
+	return r, nil
 }
 
 func (r Request) Conn() Connection {
@@ -37,6 +78,12 @@ func (r Request) Event() string {
 	return r.event
 }
 
+// Data 消息的 data, 使用不含压缩的基础编码
 func (r Request) Data() []byte {
 	return r.data
 }
+
+// Bind 使用 data 的编解码器解析 data
+func (r Request) Bind(v any) error {
+	return r.base.Unmarshal(r.data, v)
+}
@@ -0,0 +1,69 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/binbinly/pkg/codec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCodec(t *testing.T) {
+	type payload struct {
+		Name string `json:"name" msgpack:"name"`
+		Age  int    `json:"age" msgpack:"age"`
+	}
+
+	names := []string{"json", "msgpack", "json+gzip", "json+zstd", "json+snappy", "msgpack+gzip", "msgpack+zstd", "msgpack+snappy"}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			o := &Options{Codec: applyCodec(name)}
+			if !assert.NotNil(t, o.Codec) {
+				return
+			}
+			assert.Equal(t, name, o.Codec.Name())
+
+			msg, err := o.Codec.Marshal(codecMessage{Event: "chat", Data: payload{Name: "foo", Age: 18}})
+			assert.NoError(t, err)
+			req, err := NewCodecRequest(nil, o.Codec, msg)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, "chat", req.Event())
+			var got payload
+			assert.NoError(t, req.Bind(&got))
+			assert.Equal(t, payload{Name: "foo", Age: 18}, got)
+		})
+	}
+
+	// 未注册或无法解码到 any 的编码 panic
+	for _, name := range []string{"proto", "gob", "proto+gzip", "gob+zstd", "msgpak+zstd"} {
+		assert.Panics(t, func() { WithCodec(name) }, name)
+	}
+	assert.Equal(t, "msgpack+zstd", applyCodec("MsgPack+Zstd").Name())
+}
+
+func TestRequestData(t *testing.T) {
+	c := codec.GetCodec("msgpack+zstd")
+	msg, err := c.Marshal(codecMessage{Event: "chat", Data: map[string]any{"name": "foo"}})
+	assert.NoError(t, err)
+	req, err := NewCodecRequest(nil, c, msg)
+	assert.NoError(t, err)
+
+	// data 保留基础编码, 不再经过压缩
+	var got map[string]any
+	assert.NoError(t, codec.MsgpackEncoding{}.Unmarshal(req.Data(), &got))
+	assert.Equal(t, "foo", got["name"])
+
+	c = codec.GetCodec("json+gzip")
+	msg, err = c.Marshal(codecMessage{Event: "chat", Data: map[string]any{"name": "foo"}})
+	assert.NoError(t, err)
+	req, err = NewCodecRequest(nil, c, msg)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"foo"}`, string(req.Data()))
+}
+
+func applyCodec(name string) codec.Codec {
+	o := &Options{}
+	WithCodec(name)(o)
+	return o.Codec
+}
@@ -0,0 +1,37 @@
+package ws
+
+import (
+	"context"
+
+	"github.com/binbinly/pkg/errno"
+)
+
+// errorData 错误消息的 data
+type errorData struct {
+	Code    int      `json:"code" msgpack:"code"`
+	Msg     string   `json:"msg" msgpack:"msg"`
+	Details []string `json:"details,omitempty" msgpack:"details,omitempty"`
+}
+
+// Error 将 err 按连接的语言本地化后, 以请求的事件名及编解码器异步回复给客户端
+// 非 errno 错误按 errno.ErrInternalServer 处理, 不会暴露错误内容
+func (c *Context) Error(err error) error {
+	conn := c.Req.Conn()
+	lang := ""
+	if ctx := conn.Context(); ctx != nil {
+		lang = errno.Language(ctx)
+	}
+	e := errno.Localize(err, lang)
+	if e == nil {
+		e = errno.Localize(errno.Success, lang)
+	}
+
+	buf, err := c.Req.codec.Marshal(codecMessage{
+		Event: c.Req.Event(),
+		Data:  errorData{Code: e.Code(), Msg: e.Msg(), Details: e.Details()},
+	})
+	if err != nil {
+		return err
+	}
+	return conn.AsyncSend(context.Background(), 0, buf)
+}
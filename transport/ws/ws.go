@@ -147,7 +147,7 @@ func (s *wsServer) Listen() error {
 			return
 		}
 
-		conn := NewConnect(s, c, cid, uid)
+		conn := NewConnect(s, r, c, cid, uid)
 		// 添加连接至管理器
 		s.GetManager(cid).Add(conn)
 		conn.Start()